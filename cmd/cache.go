@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"nowlyric/lyrics"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the lyrics cache.",
+	Long: `Manage the lyrics cache.
+Lyrics found by the embedded and http providers, as well as "not found" results, are kept in $XDG_CACHE_HOME/nowlyric
+(~/.cache/nowlyric by default) so that they are not looked up again on every track change.`,
+}
+
+// cacheListCmd represents the cache list command
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached entries, the newest first.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cache := openCache()
+		entries, err := cache.Entries()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to read the cache:", err)
+			os.Exit(2)
+		}
+		for _, entry := range entries {
+			fmt.Printf("%s  %-8s  %-9s  %s  %s\n", shortKey(entry.Key), entry.Provider, entryStatus(entry), entry.Expires.Format(time.DateTime), entryTrack(entry))
+		}
+		fmt.Printf("%d entries in %s.\n", len(entries), cache.Dir)
+	},
+}
+
+// shortKey Shorten the key of an entry for the list, it is enough to inspect it.
+// 缩短条目的键以便列出，缩短后的键足以用于inspect。
+func shortKey(key string) string {
+	if len(key) > 12 {
+		return key[:12]
+	}
+	return key
+}
+
+// cacheInspectCmd represents the cache inspect command
+var cacheInspectCmd = &cobra.Command{
+	Use:   "inspect <key>",
+	Short: "Show a cached entry and its lyrics, the key can be shortened to a unique prefix.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry, err := openCache().Find(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		fmt.Printf("Key:      %s\n", entry.Key)
+		fmt.Printf("Provider: %s\n", entry.Provider)
+		fmt.Printf("Track:    %s\n", entryTrack(entry))
+		if entry.Duration > 0 {
+			fmt.Printf("Duration: %s\n", time.Duration(entry.Duration)*time.Microsecond)
+		}
+		fmt.Printf("Status:   %s\n", entryStatus(entry))
+		if entry.Source != "" {
+			fmt.Printf("Source:   %s\n", entry.Source)
+		}
+		fmt.Printf("Created:  %s\n", entry.Created.Format(time.DateTime))
+		fmt.Printf("Expires:  %s\n", entry.Expires.Format(time.DateTime))
+		if entry.Text != "" {
+			fmt.Println()
+			fmt.Print(entry.Text)
+		}
+	},
+}
+
+// cachePurgeCmd represents the cache purge command
+var cachePurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Remove the cached entries.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var expired = cmd.Flag("expired").Value.String() == "true"
+		removed, err := openCache().Purge(expired)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to purge the cache:", err)
+			os.Exit(2)
+		}
+		fmt.Printf("Removed %d entries.\n", removed)
+	},
+}
+
+// cacheWarmCmd represents the cache warm command
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm [directory]",
+	Short: "Look up the lyrics of every audio file in a music directory so that they are cached before they are played.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var withLog = cmd.Flag("withLog").Value.String() == "true"
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+		cache, err := newCache(cmd)
+		if err != nil || cache == nil {
+			fmt.Fprintln(os.Stderr, "The cache is disabled or cannot be opened:", err)
+			os.Exit(2)
+		}
+		providerNames, _ := cmd.Flags().GetStringSlice("providers")
+		for _, name := range providerNames {
+			if name != "embedded" && name != "http" {
+				fmt.Fprintf(os.Stderr, "Only the embedded and http providers are cached, not %q.\n", name)
+				os.Exit(2)
+			}
+		}
+		providers, err := lyrics.NewProviders(providerNames, lyrics.ProviderOptions{BaseURL: cmd.Flag("lrclibURL").Value.String(), Cache: cache})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		var found, missing, failed int
+		paths, err := lyrics.FindAudioFiles(root, func(path string, err error) {
+			failed++
+			fmt.Printf("error     %-8s  %s: %v\n", "", path, err)
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to walk the directory:", err)
+			os.Exit(2)
+		}
+		for _, path := range paths {
+			track, err := lyrics.TrackFromFile(path)
+			if err != nil && withLog {
+				fmt.Fprintf(os.Stderr, "Failed to read the tags of %s: %v\n", path, err)
+			}
+			dur, err := lyrics.SongDuration(path)
+			if err != nil && withLog {
+				fmt.Fprintf(os.Stderr, "Failed to get the duration of %s: %v\n", path, err)
+			}
+			for _, provider := range providers {
+				_, err := provider.Lyric(track, dur)
+				switch {
+				case err == nil:
+					found++
+					fmt.Printf("found     %-8s  %s\n", provider.Name(), path)
+				case errors.Is(err, lyrics.ErrNoLyrics):
+					missing++
+					fmt.Printf("not found %-8s  %s\n", provider.Name(), path)
+				default:
+					failed++
+					fmt.Printf("error     %-8s  %s: %v\n", provider.Name(), path, err)
+				}
+			}
+		}
+		fmt.Printf("Checked %d audio files: %d found, %d not found, %d errors.\n", len(paths), found, missing, failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// addCacheFlags Add the flags setting how long cached results are kept.
+// 添加设置缓存结果保留时长的标志。
+func addCacheFlags(cmd *cobra.Command) {
+	cmd.Flags().Duration("cacheTTL", lyrics.DefaultCacheTTL, "How long lyrics found by the embedded and http providers are cached. Set it to 0 to turn the cache off.")
+	cmd.Flags().Duration("cacheMissTTL", lyrics.DefaultCacheMissTTL, "How long a \"not found\" result of the embedded and http providers is cached before they are asked again.")
+}
+
+// newCache Open the cache with the TTLs of the flags, returns nil if the cache is turned off.
+// 按标志中的保留时长打开缓存，缓存被关闭时返回nil。
+func newCache(cmd *cobra.Command) (*lyrics.LyricCache, error) {
+	ttl, _ := cmd.Flags().GetDuration("cacheTTL")
+	if ttl <= 0 {
+		return nil, nil
+	}
+	cache, err := lyrics.NewLyricCache()
+	if err != nil {
+		return nil, err
+	}
+	cache.TTL = ttl
+	cache.MissTTL, _ = cmd.Flags().GetDuration("cacheMissTTL")
+	return cache, nil
+}
+
+// openCache Open the cache for the management commands, exits if the cache directory is unknown.
+// 为管理命令打开缓存，无法确定缓存目录时退出。
+func openCache() *lyrics.LyricCache {
+	cache, err := lyrics.NewLyricCache()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to find the cache directory:", err)
+		os.Exit(2)
+	}
+	return cache
+}
+
+// entryStatus Describe whether the entry holds lyrics and whether it is still used.
+// 描述条目是否包含歌词以及是否仍被使用。
+func entryStatus(entry *lyrics.CacheEntry) string {
+	switch {
+	case entry.Expired():
+		return "expired"
+	case entry.Found:
+		return "found"
+	}
+	return "not found"
+}
+
+// entryTrack Describe the track of the entry by its audio file, otherwise by its artist and title.
+// 描述条目的曲目，优先使用音频文件，否则使用歌手和标题。
+func entryTrack(entry *lyrics.CacheEntry) string {
+	return lyrics.Track{Path: entry.Path, Title: entry.Title, Artists: []string{entry.Artist}}.Describe()
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd, cacheInspectCmd, cachePurgeCmd, cacheWarmCmd)
+	cachePurgeCmd.Flags().Bool("expired", false, "Only remove the expired entries.")
+	cacheWarmCmd.Flags().StringSlice("providers", []string{"embedded", "http"}, "The cached providers to look up the lyrics with, embedded and http.")
+	cacheWarmCmd.Flags().String("lrclibURL", lyrics.DefaultLRCLIBURL, "The base URL of the LRCLIB-compatible server used by the http provider.")
+	cacheWarmCmd.Flags().BoolP("withLog", "l", false, "Whether to output logs.")
+	addCacheFlags(cacheWarmCmd)
+}
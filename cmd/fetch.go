@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"nowlyric/lyrics"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// fetchCmd represents the fetch command
+var fetchCmd = &cobra.Command{
+	Use:   "fetch [directory]",
+	Short: "Download the lyrics of a music directory as lyric files next to the audio files.",
+	Long: `Download the lyrics of a music directory as lyric files next to the audio files.
+Every audio file is looked up with the providers by the artist, title and album in its tags and its duration, and the lyrics found are written as an .lrc file with the same name.
+Exits with status 1 if any lookup fails.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var withLog = cmd.Flag("withLog").Value.String() == "true"
+		var dryRun = cmd.Flag("dryRun").Value.String() == "true"
+		var overwrite = cmd.Flag("overwrite").Value.String()
+		switch overwrite {
+		case lyrics.OverwriteNever, lyrics.OverwriteUnsynced, lyrics.OverwriteAlways:
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown overwrite policy %q, expected never, unsynced or always.\n", overwrite)
+			os.Exit(2)
+		}
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		if concurrency < 1 {
+			fmt.Fprintln(os.Stderr, "The concurrency must be at least 1.")
+			os.Exit(2)
+		}
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+		providerNames, _ := cmd.Flags().GetStringSlice("providers")
+		for _, name := range providerNames {
+			if name == "sidecar" || name == "player" {
+				fmt.Fprintf(os.Stderr, "The %s provider cannot be used to fetch lyrics, use search, embedded or http.\n", name)
+				os.Exit(2)
+			}
+		}
+		var search lyrics.LyricSearch
+		search.Dirs, _ = cmd.Flags().GetStringArray("lyricDir")
+		search.Threshold, _ = cmd.Flags().GetFloat64("matchThreshold")
+		cache, err := newCache(cmd)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to open the cache, lyrics are looked up without it:", err)
+		}
+		providers, err := lyrics.NewProviders(providerNames, lyrics.ProviderOptions{Search: search, BaseURL: cmd.Flag("lrclibURL").Value.String(), Cache: cache})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		results, err := lyrics.FetchLibrary(root, lyrics.FetchOptions{Providers: providers, Overwrite: overwrite, DryRun: dryRun, Concurrency: concurrency, WithLog: withLog})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to walk the directory:", err)
+			os.Exit(2)
+		}
+		var counts [lyrics.FetchFailed + 1]int
+		for _, result := range results {
+			counts[result.Status]++
+			switch result.Status {
+			case lyrics.FetchWritten:
+				action := "wrote"
+				if dryRun {
+					action = "would write"
+				}
+				if result.Replaced == result.Lyric {
+					fmt.Printf("%s %s from %s, replacing it\n", action, result.Lyric, result.Source)
+				} else if result.Replaced != "" {
+					fmt.Printf("%s %s from %s, shadowing %s\n", action, result.Lyric, result.Source, result.Replaced)
+				} else {
+					fmt.Printf("%s %s from %s\n", action, result.Lyric, result.Source)
+				}
+			case lyrics.FetchSkipped:
+				if withLog {
+					fmt.Printf("skip %s: %v\n", result.Lyric, result.Err)
+				}
+			case lyrics.FetchNotFound:
+				fmt.Printf("not found %s\n", result.Audio)
+			default:
+				fmt.Printf("error %s: %v\n", result.Audio, result.Err)
+			}
+		}
+		written := "written"
+		if dryRun {
+			written = "to write"
+		}
+		fmt.Printf("Checked %d audio files: %d %s, %d skipped, %d not found, %d errors.\n", len(results), counts[lyrics.FetchWritten], written, counts[lyrics.FetchSkipped], counts[lyrics.FetchNotFound], counts[lyrics.FetchFailed])
+		if counts[lyrics.FetchFailed] > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fetchCmd)
+	fetchCmd.Flags().StringSlice("providers", []string{"http"}, "The lyric providers asked in order: search (the lyric directories and fuzzy matching), embedded (the tags of the audio file) and http (an LRCLIB-compatible server).")
+	fetchCmd.Flags().String("lrclibURL", lyrics.DefaultLRCLIBURL, "The base URL of the LRCLIB-compatible server used by the http provider.")
+	fetchCmd.Flags().StringArray("lyricDir", nil, "A directory searched by the search provider, can be given several times.")
+	fetchCmd.Flags().Float64("matchThreshold", lyrics.DefaultMatchThreshold, "The lowest score a lyric file found by fuzzy matching needs, between 0 and 1.")
+	fetchCmd.Flags().StringP("overwrite", "w", lyrics.OverwriteNever, "What to do with audio files that already have a lyric file: never replaces them, unsynced replaces .lrc and .txt files without timing by synchronized lyrics, always replaces them or writes a lyric file that is found before them.")
+	fetchCmd.Flags().BoolP("dryRun", "n", false, "Only report the lyric files that would be written.")
+	fetchCmd.Flags().IntP("concurrency", "j", 4, "How many audio files are looked up at the same time.")
+	fetchCmd.Flags().BoolP("withLog", "l", false, "Whether to output logs.")
+	addCacheFlags(fetchCmd)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"nowlyric/lyrics"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint [directory]",
+	Short: "Check the lyric files of a music directory.",
+	Long: `Check the lyric files of a music directory.
+Reports audio files without lyrics, unparseable lines, timestamps beyond the audio duration, unsorted or duplicate timestamps and encoding problems.
+Exits with status 1 if any error is found.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var withLog = cmd.Flag("withLog").Value.String() == "true"
+		var format = cmd.Flag("format").Value.String()
+		if format != "text" && format != "json" {
+			fmt.Fprintf(os.Stderr, "Unknown format %q, expected text or json.\n", format)
+			os.Exit(2)
+		}
+		var minSeverity lyrics.Severity
+		if err := minSeverity.UnmarshalText([]byte(cmd.Flag("severity").Value.String())); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		root := "."
+		if len(args) > 0 {
+			root = args[0]
+		}
+		reports, err := lyrics.LintLibrary(root, withLog)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to walk the directory:", err)
+			os.Exit(2)
+		}
+		var errorCount, warningCount, missing, unreadableCount int
+		for i := range reports {
+			var kept []lyrics.Diagnostic
+			unreadable := false
+			for _, d := range reports[i].Diagnostics {
+				switch d.Severity {
+				case lyrics.SeverityError:
+					errorCount++
+					unreadable = reports[i].Lyric == ""
+				case lyrics.SeverityWarning:
+					warningCount++
+				}
+				if d.Severity >= minSeverity {
+					kept = append(kept, d)
+				}
+			}
+			//Reports of directories that cannot be read have no lyric file either.
+			//无法读取的目录的报告同样没有歌词文件。
+			if unreadable {
+				unreadableCount++
+			} else if reports[i].Lyric == "" {
+				missing++
+			}
+			reports[i].Diagnostics = append([]lyrics.Diagnostic{}, kept...)
+		}
+		switch format {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(reports); err != nil {
+				fmt.Fprintln(os.Stderr, "Failed to encode the report:", err)
+				os.Exit(2)
+			}
+		default:
+			for _, report := range reports {
+				for _, d := range report.Diagnostics {
+					fmt.Println(d.Error())
+				}
+			}
+			fmt.Printf("Checked %d audio files: %d without lyrics, %d errors, %d warnings.\n", len(reports)-unreadableCount, missing, errorCount, warningCount)
+		}
+		if errorCount > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringP("format", "f", "text", "The output format, text or json.")
+	lintCmd.Flags().String("severity", "warning", "The minimum severity of the problems to output, info, warning or error.")
+	lintCmd.Flags().BoolP("withLog", "l", false, "Whether to output logs.")
+}
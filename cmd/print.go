@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"nowlyric/lyrics"
 	"strconv"
+	"strings"
 	"unsafe"
 
 	"github.com/spf13/cobra"
@@ -31,12 +32,67 @@ var printCmd = &cobra.Command{
 		var withLog = cmd.Flag("withLog").Value.String() == "true"
 		var delayStr = cmd.Flag("delay").Value.String()
 		var onlyTranslation = cmd.Flag("onlyTranslation").Value.String() == "true"
+		var onlyOriginal = cmd.Flag("onlyOriginal").Value.String() == "true"
+		var showRomanization = cmd.Flag("showRomanization").Value.String() == "true"
+		var showAgent = cmd.Flag("showAgent").Value.String() == "true"
 		var richText = cmd.Flag("richText").Value.String() == "true"
 		var supportExecute = cmd.Flag("supportExecute").Value.String() == "true"
 		var playedTextColor = cmd.Flag("playedTextColor").Value.String()
 		var unplayedTextColor = cmd.Flag("unplayedTextColor").Value.String()
 		var defaultContent = cmd.Flag("defaultContent").Value.String()
 		var sharedMemory = cmd.Flag("sharedMemory").Value.String() == "true"
+		var reload = cmd.Flag("reload").Value.String() == "true"
+		var lyricEncoding = cmd.Flag("lyricEncoding").Value.String()
+		if err := lyrics.CheckEncoding(lyricEncoding); err != nil {
+			println("Invalid lyricEncoding:", err.Error())
+			return
+		}
+		lyricDirs, _ := cmd.Flags().GetStringArray("lyricDir")
+		lyricTemplates, _ := cmd.Flags().GetStringArray("lyricTemplate")
+		matchThreshold, _ := cmd.Flags().GetFloat64("matchThreshold")
+		providerNames, _ := cmd.Flags().GetStringSlice("providers")
+		cache, err := newCache(cmd)
+		if err != nil {
+			println("Failed to open the lyrics cache:", err.Error())
+			return
+		}
+		providers, err := lyrics.NewProviders(providerNames, lyrics.ProviderOptions{
+			Encoding: lyricEncoding,
+			Search:   lyrics.LyricSearch{Dirs: lyricDirs, Templates: lyricTemplates, Threshold: matchThreshold},
+			BaseURL:  cmd.Flag("lrclibURL").Value.String(),
+			Cache:    cache,
+		})
+		if err != nil {
+			println("Invalid providers:", err.Error())
+			return
+		}
+		var unsyncedMode = cmd.Flag("unsyncedMode").Value.String()
+		if unsyncedMode != lyrics.UnsyncedScroll && unsyncedMode != lyrics.UnsyncedBlock {
+			println("Invalid unsyncedMode:", unsyncedMode, "(expected scroll or block)")
+			return
+		}
+		var playerPolicy = cmd.Flag("playerPolicy").Value.String()
+		if playerPolicy != lyrics.PolicyPlaying && playerPolicy != lyrics.PolicyRecent && playerPolicy != lyrics.PolicyPriority {
+			println("Invalid playerPolicy:", playerPolicy, "(expected playing, recent or priority)")
+			return
+		}
+		playerPriority, _ := cmd.Flags().GetStringSlice("playerPriority")
+		players, _ := cmd.Flags().GetStringSlice("player")
+		ignorePlayers, _ := cmd.Flags().GetStringSlice("ignorePlayer")
+		for _, patterns := range [][]string{players, ignorePlayers, playerPriority} {
+			for _, pattern := range patterns {
+				if err := lyrics.CheckPlayerPattern(pattern); err != nil {
+					println("Invalid player:", err.Error())
+					return
+				}
+			}
+		}
+		for _, template := range lyricTemplates {
+			if err := lyrics.CheckLyricTemplate(template); err != nil {
+				println("Invalid lyricTemplate:", err.Error())
+				return
+			}
+		}
 		//指针默认为null，只有使用sharedMemory才为其赋值
 		var ptr unsafe.Pointer
 		var mmapOK = false
@@ -74,14 +130,18 @@ var printCmd = &cobra.Command{
 		if err != nil {
 			delayVal = 100
 		}
-		MPrisListener := &lyrics.MPrisListener{}
+		MPrisListener := &lyrics.MPrisListener{Providers: providers, UnsyncedMode: unsyncedMode, Reload: reload, Policy: playerPolicy, Priority: playerPriority, Players: players, IgnorePlayers: ignorePlayers}
 		err = MPrisListener.ConnectSessionBus(withLog)
 		if err != nil {
 			return
 		}
-		MPrisListener.CallBack = &lyrics.LyricCallback{OnlyTranslation: onlyTranslation, RichText: richText, SupportExecute: supportExecute, PlayedTextColor: playedTextColor, UnplayedTextColor: unplayedTextColor, Offset: offset, WithLog: withLog, MmapOK: mmapOK, Ptr: ptr, DefaultContent: defaultContent}
+		MPrisListener.CallBack = &lyrics.LyricCallback{OnlyTranslation: onlyTranslation, OnlyOriginal: onlyOriginal, ShowRomanization: showRomanization, ShowAgent: showAgent, RichText: richText, SupportExecute: supportExecute, PlayedTextColor: playedTextColor, UnplayedTextColor: unplayedTextColor, Offset: offset, WithLog: withLog, MmapOK: mmapOK, Ptr: ptr, DefaultContent: defaultContent}
 		go MPrisListener.SynchronizedLyrics(withLog, uint32(delayVal))
-		println("The lyrics monitoring process is ready. It will take effect when you start playing music or switch to the next song.")
+		if MPrisListener.LoadPlayingTrack(withLog) {
+			println("The lyrics monitoring process is ready.")
+		} else {
+			println("The lyrics monitoring process is ready. It will take effect when you start playing music.")
+		}
 		MPrisListener.WatchPlayerEvents(withLog)
 	},
 }
@@ -92,10 +152,26 @@ func init() {
 	printCmd.Flags().Uint32P("delay", "d", 100, "The delay for synchronizing lyrics, measured in milliseconds.")
 	printCmd.Flags().BoolP("withLog", "l", false, "Whether to output logs.")
 	printCmd.Flags().BoolP("onlyTranslation", "t", false, "Only display the translation.")
+	printCmd.Flags().BoolP("onlyOriginal", "o", false, "Only display the original lyrics without the translation.")
+	printCmd.Flags().Bool("showRomanization", false, "Also display the romanization, such as romaji or pinyin, if the lyric file has one.")
+	printCmd.Flags().Bool("showAgent", false, "Put the name of the singer before the lyrics if the lyric file attributes lines to singers, as TTML files do.")
 	printCmd.Flags().BoolP("richText", "r", false, "Use colored text. For example: <span foreground='color'>text</span>.")
 	printCmd.Flags().BoolP("supportExecute", "e", false, "richText needs to be enabled.Support for Executor-Gnome Shell Extension color font format.After enabling it, <executor.markup.true> will be added before the output.")
 	printCmd.Flags().StringP("playedTextColor", "p", "#FFFFFF", "richText needs to be enabled.Define the text color of the played part, with the default being #FFFFFF.")
 	printCmd.Flags().StringP("unplayedTextColor", "u", "#FFFFFF", "richText needs to be enabled.Define the text color for the unplayed part, with the default being #FFFFFF.")
 	printCmd.Flags().BoolP("sharedMemory", "s", false, "Create a memory area on your device that can be shared by multiple processes using shared memory. Note: To use the nowlyric read command, this flag needs to be enabled.")
+	printCmd.Flags().String("lyricEncoding", "", "The encoding of the lyric files, such as GBK, Big5, Shift_JIS or UTF-16LE. By default the encoding is detected automatically, use this flag for files the detection gets wrong.")
+	printCmd.Flags().StringArray("lyricDir", nil, "A directory to look for lyric files in when there is none next to the audio file, such as ~/Music/Lyrics. Can be given several times, the directories are searched in order.")
+	printCmd.Flags().StringArray("lyricTemplate", nil, "A file name template used in the lyric directories, without the extension. The fields {artist}, {album}, {title}, {track} and {basename} are filled from the player metadata, for example {artist}/{title}. Can be given several times. (default \""+strings.Join(lyrics.DefaultLyricTemplates, "\", \"")+"\")")
+	printCmd.Flags().Float64("matchThreshold", lyrics.DefaultMatchThreshold, "The lowest score between 0 and 1 a lyric file needs when no file name matches exactly and the file is chosen by comparing its name with the artist and title of the song. Set it above 1 to turn fuzzy matching off.")
+	printCmd.Flags().StringSlice("providers", lyrics.DefaultProviderOrder, "The lyric providers tried in order: sidecar (the lyric file next to the audio file), search (the lyric directories and fuzzy matching), player (xesam:asText), embedded (the tags of the audio file) and http (an LRCLIB-compatible server).")
+	printCmd.Flags().String("lrclibURL", lyrics.DefaultLRCLIBURL, "The base URL of the LRCLIB-compatible server used by the http provider, such as a self-hosted lyrics server.")
+	addCacheFlags(printCmd)
+	printCmd.Flags().String("unsyncedMode", lyrics.UnsyncedScroll, "How lyrics without timing, such as .txt files, are displayed: scroll spreads the lines over the song as an estimate, block shows all lines at once.")
+	printCmd.Flags().Bool("reload", true, "Reload the lyric file when it is saved while the song is playing, use --reload=false to turn it off.")
+	printCmd.Flags().String("playerPolicy", lyrics.PolicyPlaying, "Which player the lyrics are shown for when several players are running: playing prefers a playing player, recent the player that last started playing even if it paused, priority the players of playerPriority.")
+	printCmd.Flags().StringSlice("playerPriority", nil, "The players preferred in order by the priority policy, as patterns like those of --player, such as spotify,vlc.")
+	printCmd.Flags().StringSlice("player", nil, "Only show the lyrics of these players. A player is given by a glob pattern matched against its bus name, with or without org.mpris.MediaPlayer2., or its Identity, such as spotify, 'org.mpris.MediaPlayer2.vlc' or 'VLC media player'.")
+	printCmd.Flags().StringSlice("ignorePlayer", nil, "Never show the lyrics of these players, such as firefox*,chromium*. The patterns are matched like those of --player.")
 	printCmd.Flags().Float64("offset", 0.05, "The offset used for the playback progress. Between 0 and 1. For example: This line of lyrics has actually been played by 50%. The program will add an offset to generate the rendered text. If the offset is 0.1, then 50%+0.1 (10%) =60%.Default 0.05 (%5).")
 }
@@ -11,7 +11,10 @@ var rootCmd = &cobra.Command{
 	Short: "Get the lyrics corresponding to the currently playing music.",
 	Long: `Get the lyrics corresponding to the currently playing music. 
 The song files and lyrics files must be placed in the same-level directory and have matching file names. 
-For example: a.lac matches a.lrc.`,
+For example: a.lac matches a.lrc. Lyrics in the TTML (a.ttml), NetEase YRC (a.yrc), QQ Music QRC (a.qrc) and Kugou KRC (a.krc) formats
+and subtitles in the ASS/SSA (a.ass, a.ssa), SubRip (a.srt) and WebVTT (a.vtt) formats are also supported,
+as well as plain text lyrics without timing (a.txt).
+Use nowlyric fetch to download the missing lyric files of a music directory.`,
 }
 
 func Execute() {
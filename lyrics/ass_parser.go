@@ -0,0 +1,185 @@
+package lyrics
+
+import (
+	"bufio"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	assOverrideRegex = regexp.MustCompile(`\{[^}]*}`)
+	assKaraokeRegex  = regexp.MustCompile(`\\(?:kf|ko|k|K)(\d+(?:\.\d+)?)`)
+)
+
+// defaultASSFormat The field order of dialogue events when the [Events] section has no Format line.
+// [Events]段没有Format行时对话事件的字段顺序。
+var defaultASSFormat = []string{"layer", "start", "end", "style", "name", "marginl", "marginr", "marginv", "effect", "text"}
+
+// parseASS Parse the dialogue events of an ASS/SSA subtitle file. Karaoke tags (\k, \K, \kf and \ko, in centiseconds)
+// become syllable timing, other override tags are ignored and the Name (actor) field becomes the singer.
+// Events starting at the same time are treated like LRC lines sharing a timestamp.
+// 解析ASS/SSA字幕文件中的对话事件。卡拉OK标签（\k、\K、\kf和\ko，单位为厘秒）转换为音节时间，其他覆盖标签被忽略，Name（角色）字段作为歌手。
+// 同时开始的事件与共享时间戳的LRC行按相同方式处理。
+func parseASS(p *lyricParser, data []byte, duration uint64) (*Lyric, error) {
+	text, err := p.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	var lines []LyricLine
+	var metadata Metadata
+	byTime := make(map[uint64]int)
+	format := defaultASSFormat
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(nil, 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(line)
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch section {
+		case "[script info]":
+			if key == "Title" && value != "<untitled>" {
+				metadata.Title = value
+			}
+		case "[events]":
+			switch key {
+			case "Format":
+				format = nil
+				for _, field := range strings.Split(value, ",") {
+					format = append(format, strings.ToLower(strings.TrimSpace(field)))
+				}
+			case "Dialogue":
+				lyricLine, err := p.parseDialogue(lineNo, value, format)
+				if err != nil {
+					return nil, err
+				}
+				if lyricLine == nil {
+					continue
+				}
+				if duration > 0 && lyricLine.TimeUs > duration {
+					if err := p.report(lineNo, 1, SeverityWarning, "dialogue at %s is past the end of the song", formatTimestamp(lyricLine.TimeUs)); err != nil {
+						return nil, err
+					}
+				}
+				if idx, ok := byTime[lyricLine.TimeUs]; ok {
+					if !lines[idx].addSubLine(lyricLine.Text) {
+						if err := p.report(lineNo, 1, SeverityWarning, "more than three dialogues start at %s, the dialogue is ignored", formatTimestamp(lyricLine.TimeUs)); err != nil {
+							return nil, err
+						}
+					}
+					continue
+				}
+				byTime[lyricLine.TimeUs] = len(lines)
+				lines = append(lines, *lyricLine)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].TimeUs < lines[j].TimeUs
+	})
+	return &Lyric{Lines: lines, Duration: duration, Metadata: metadata}, nil
+}
+
+// parseDialogue Parse the value of a Dialogue line, returns nil if the event is broken or has no text.
+// 解析Dialogue行的值，事件有问题或没有文本时返回nil。
+func (p *lyricParser) parseDialogue(lineNo int, value string, format []string) (*LyricLine, error) {
+	fields := strings.SplitN(value, ",", len(format))
+	if len(fields) < len(format) {
+		return nil, p.report(lineNo, 1, SeverityError, "dialogue has %d fields, the format expects %d", len(fields), len(format))
+	}
+	field := func(name string) string {
+		for i, f := range format {
+			if f == name {
+				return strings.TrimSpace(fields[i])
+			}
+		}
+		return ""
+	}
+	startUs, err := parseTimestamp(field("start"))
+	if err != nil {
+		return nil, p.report(lineNo, 1, SeverityError, "invalid start %q: %v", field("start"), err)
+	}
+	endUs, err := parseTimestamp(field("end"))
+	if err != nil {
+		return nil, p.report(lineNo, 1, SeverityError, "invalid end %q: %v", field("end"), err)
+	}
+	raw := fields[len(fields)-1]
+	for i, f := range format {
+		if f == "text" {
+			raw = fields[i]
+		}
+	}
+	line := &LyricLine{TimeUs: startUs, EndUs: endUs, Agent: field("name")}
+	line.Words = parseASSKaraoke(raw, startUs)
+	if len(line.Words) > 0 {
+		line.Words = trimWords(line.Words)
+		line.Text = joinWords(line.Words)
+	} else {
+		line.Text = strings.TrimSpace(assPlainText(assOverrideRegex.ReplaceAllString(raw, "")))
+	}
+	if line.Text == "" {
+		return nil, p.report(lineNo, 1, SeverityInfo, "dialogue has no text")
+	}
+	return line, nil
+}
+
+// parseASSKaraoke Split the text of a dialogue into syllables by its karaoke tags, each tag gives the duration of the syllable after it.
+// Returns nil if the dialogue has no karaoke tags.
+// 按卡拉OK标签将对话文本拆分为音节，每个标签给出其后音节的时长。没有卡拉OK标签时返回nil。
+func parseASSKaraoke(raw string, startUs uint64) []LyricWord {
+	var words []LyricWord
+	karaoke := false
+	cursor := startUs
+	locs := assOverrideRegex.FindAllStringIndex(raw, -1)
+	if len(locs) > 0 && strings.TrimSpace(raw[:locs[0][0]]) != "" {
+		words = append(words, LyricWord{TimeUs: startUs, EndUs: startUs, Text: assPlainText(raw[:locs[0][0]])})
+	}
+	for i, loc := range locs {
+		match := assKaraokeRegex.FindStringSubmatch(raw[loc[0]:loc[1]])
+		textEnd := len(raw)
+		if i+1 < len(locs) {
+			textEnd = locs[i+1][0]
+		}
+		text := assPlainText(raw[loc[1]:textEnd])
+		if match == nil {
+			//A block with only styling, its text still belongs to the current syllable.
+			//只有样式的块，其文本仍属于当前音节。
+			if n := len(words); n > 0 {
+				words[n-1].Text += text
+			}
+			continue
+		}
+		karaoke = true
+		centiseconds, _ := strconv.ParseFloat(match[1], 64)
+		end := cursor + uint64(centiseconds*10_000)
+		words = append(words, LyricWord{TimeUs: cursor, EndUs: end, Text: text})
+		cursor = end
+	}
+	if !karaoke {
+		return nil
+	}
+	return words
+}
+
+// assPlainText Replace the ASS line breaks and hard spaces with spaces.
+// 将ASS的换行符和硬空格替换为空格。
+func assPlainText(text string) string {
+	return strings.NewReplacer(`\N`, " ", `\n`, " ", `\h`, " ").Replace(text)
+}
@@ -0,0 +1,83 @@
+package lyrics
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseASSKaraoke(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []LyricWord
+	}{
+		{`Hello{\i1}world`, nil},
+		{`{\k50}Hel{\kf150}lo`, []LyricWord{{TimeUs: 1_000_000, EndUs: 1_500_000, Text: "Hel"}, {TimeUs: 1_500_000, EndUs: 3_000_000, Text: "lo"}}},
+		{`{\K25.5}a{\ko25}b`, []LyricWord{{TimeUs: 1_000_000, EndUs: 1_255_000, Text: "a"}, {TimeUs: 1_255_000, EndUs: 1_505_000, Text: "b"}}},
+		//Styling inside a syllable belongs to it, text before the first tag starts with the line.
+		//音节内的样式属于该音节，第一个标签之前的文本从行开始时开始。
+		{`{\k50}a{\b1}b{\k50}c`, []LyricWord{{TimeUs: 1_000_000, EndUs: 1_500_000, Text: "ab"}, {TimeUs: 1_500_000, EndUs: 2_000_000, Text: "c"}}},
+		{`x{\k50}y\Nz`, []LyricWord{{TimeUs: 1_000_000, EndUs: 1_000_000, Text: "x"}, {TimeUs: 1_000_000, EndUs: 1_500_000, Text: "y z"}}},
+	}
+	for _, tt := range tests {
+		if words := parseASSKaraoke(tt.raw, 1_000_000); !slices.Equal(words, tt.want) {
+			t.Errorf("parseASSKaraoke(%q) = %v, want %v", tt.raw, words, tt.want)
+		}
+	}
+}
+
+func TestParseASS(t *testing.T) {
+	script := "[Script Info]\nTitle: Song\nScriptType: v4.00+\n\n" +
+		"[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n" +
+		"[Events]\nFormat: Start, End, Name, Text\n" +
+		"Dialogue: 0:00:05.00,0:00:06.00,,Second, with a comma\n" +
+		"Comment: 0:00:03.00,0:00:04.00,,Skipped\n" +
+		"Dialogue: 0:00:01.00,0:00:03.00,Singer,{\\k50}Hel{\\k150}lo\n" +
+		"Dialogue: 0:00:07.00,0:00:08.00,,{\\pos(1,2)}\n"
+	p := &lyricParser{file: "test.ass"}
+	lyric, err := parseASS(p, []byte(script), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lyric.Metadata.Title != "Song" {
+		t.Errorf("title = %q, want %q", lyric.Metadata.Title, "Song")
+	}
+	if len(lyric.Lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lyric.Lines))
+	}
+	if first := lyric.Lines[0]; first.Text != "Hello" || first.Agent != "Singer" || first.EndUs != 3_000_000 || len(first.Words) != 2 {
+		t.Errorf("first line = %+v", first)
+	}
+	if second := lyric.Lines[1]; second.Text != "Second, with a comma" || second.Words != nil {
+		t.Errorf("second line = %+v", second)
+	}
+	//The event without text is reported.
+	//没有文本的事件会被报告。
+	if len(p.diagnostics) != 1 || p.diagnostics[0].Severity != SeverityInfo {
+		t.Errorf("diagnostics = %v", p.diagnostics)
+	}
+}
+
+func TestParseASSSharedStart(t *testing.T) {
+	//The second and third dialogue starting together are the translation and the romanization, a fourth one is ignored.
+	//同时开始的第二条和第三条对白为翻译和罗马音，第四条会被忽略。
+	script := "[Events]\nFormat: Start, End, Text\n" +
+		"Dialogue: 0:00:01.00,0:00:02.00,こんにちは\n" +
+		"Dialogue: 0:00:01.00,0:00:02.00,Hello\n" +
+		"Dialogue: 0:00:01.00,0:00:02.00,konnichiwa\n" +
+		"Dialogue: 0:00:01.00,0:00:02.00,Extra\n" +
+		"Dialogue: 0:00:03.00,0:00:04.00,Next\n"
+	p := &lyricParser{file: "test.ass"}
+	lyric, err := parseASS(p, []byte(script), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lyric.Lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lyric.Lines))
+	}
+	if first := lyric.Lines[0]; first.Text != "こんにちは" || first.Translation != "Hello" || first.Romanization != "konnichiwa" {
+		t.Errorf("first line = %+v", first)
+	}
+	if len(p.diagnostics) != 1 || p.diagnostics[0].Severity != SeverityWarning || p.diagnostics[0].Line != 6 {
+		t.Errorf("diagnostics = %v, want the ignored dialogue", p.diagnostics)
+	}
+}
@@ -0,0 +1,417 @@
+package lyrics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+
+	ffmpeggo "github.com/u2takey/ffmpeg-go"
+)
+
+// mpegBitrates The bitrates of MPEG audio frames in kbit/s by MPEG version (1 or 2 and 2.5), layer and bitrate index.
+// MPEG音频帧的比特率（kbit/s），按MPEG版本（1或2和2.5）、层和比特率索引排列。
+var mpegBitrates = [2][3][15]uint64{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// mpegSampleRates The sample rates of MPEG audio frames by MPEG version (1, 2, 2.5) and sample rate index.
+// MPEG音频帧的采样率，按MPEG版本（1、2、2.5）和采样率索引排列。
+var mpegSampleRates = [3][3]uint64{{44100, 48000, 32000}, {22050, 24000, 16000}, {11025, 12000, 8000}}
+
+// adtsSampleRates The sample rates of ADTS AAC frames by sample rate index.
+// ADTS AAC帧的采样率，按采样率索引排列。
+var adtsSampleRates = []uint64{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// SongDuration Get the duration of the audio file in microseconds. It is read from the headers of the file,
+// ffprobe is only used if it is installed and the format is not understood.
+// 获取音频文件时长（单位：微秒）。时长从文件头部读取，仅当格式无法识别且安装了ffprobe时才使用ffprobe。
+func SongDuration(audioFilePath string) (uint64, error) {
+	duration, err := AudioDuration(audioFilePath)
+	if err == nil {
+		return duration, nil
+	}
+	if _, lookErr := exec.LookPath("ffprobe"); lookErr != nil {
+		return 0, err
+	}
+	duration, probeErr := probeDuration(audioFilePath)
+	if probeErr != nil {
+		return 0, fmt.Errorf("%v, and ffprobe failed: %v", err, probeErr)
+	}
+	return duration, nil
+}
+
+// AudioDuration Read the duration of the audio file in microseconds from its headers: the Xing, Info or VBRI header
+// or the bitrate of MP3 files, the frames of ADTS AAC files, FLAC STREAMINFO, the data chunk of WAV files, the last
+// granule position of Ogg Vorbis and Opus files and the mvhd atom of MP4/M4A files.
+// 从文件头部读取音频文件时长（单位：微秒）：MP3文件的Xing、Info或VBRI头部或比特率，ADTS AAC文件的帧，FLAC的STREAMINFO，
+// WAV文件的data块，Ogg Vorbis和Opus文件的最后一个granule位置，以及MP4/M4A文件的mvhd原子。
+func AudioDuration(path string) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+
+		}
+	}(file)
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	magic := make([]byte, 12)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return 0, fmt.Errorf("failed to read the file header: %v", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte("fLaC")):
+		return flacDuration(file)
+	case bytes.HasPrefix(magic, []byte("OggS")):
+		return oggDuration(file, info.Size())
+	case bytes.Equal(magic[4:8], []byte("ftyp")):
+		return mp4Duration(file)
+	case bytes.HasPrefix(magic, []byte("RIFF")) && bytes.Equal(magic[8:12], []byte("WAVE")):
+		return wavDuration(file, info.Size())
+	}
+	return mpegDuration(file, info.Size())
+}
+
+// flacDuration Read the sample rate and the number of samples from the STREAMINFO block.
+// 从STREAMINFO块读取采样率和采样数。
+func flacDuration(r io.ReadSeeker) (uint64, error) {
+	var streamInfo []byte
+	err := readFLACBlocks(r, func(blockType byte, block []byte) {
+		streamInfo = block
+	}, 0)
+	if err != nil {
+		return 0, err
+	}
+	if len(streamInfo) < 18 {
+		return 0, fmt.Errorf("the FLAC file has no STREAMINFO block")
+	}
+	sampleRate := uint64(streamInfo[10])<<12 | uint64(streamInfo[11])<<4 | uint64(streamInfo[12])>>4
+	samples := uint64(streamInfo[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(streamInfo[14:18]))
+	if sampleRate == 0 || samples == 0 {
+		return 0, fmt.Errorf("the FLAC STREAMINFO block has no number of samples")
+	}
+	return samples * 1_000_000 / sampleRate, nil
+}
+
+// wavDuration Divide the size of the data chunk by the byte rate of the fmt chunk.
+// 用data块的大小除以fmt块的字节率。
+func wavDuration(r io.ReadSeeker, fileSize int64) (uint64, error) {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return 0, err
+	}
+	header := make([]byte, 8)
+	var byteRate uint64
+	for offset := int64(12); ; {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, fmt.Errorf("the WAV file has no data chunk")
+		}
+		offset += 8
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		switch string(header[:4]) {
+		case "fmt ":
+			format := make([]byte, min(size, 16))
+			if _, err := io.ReadFull(r, format); err != nil || len(format) < 12 {
+				return 0, fmt.Errorf("truncated WAV fmt chunk")
+			}
+			byteRate = uint64(binary.LittleEndian.Uint32(format[8:12]))
+			if _, err := r.Seek(offset+size+size%2, io.SeekStart); err != nil {
+				return 0, err
+			}
+		case "data":
+			if byteRate == 0 {
+				return 0, fmt.Errorf("the WAV file has no fmt chunk before its data")
+			}
+			//Streamed files leave the size unset, the data then runs to the end of the file.
+			//流式写入的文件不设置大小，此时数据一直延续到文件末尾。
+			size = min(size, fileSize-offset)
+			return uint64(size) * 1_000_000 / byteRate, nil
+		default:
+			//Chunks are padded to an even size.
+			//块会被填充到偶数大小。
+			if _, err := r.Seek(offset+size+size%2, io.SeekStart); err != nil {
+				return 0, err
+			}
+		}
+		offset += size + size%2
+	}
+}
+
+// oggDuration Divide the granule position of the last page of the stream by the sample rate of its codec, Opus
+// always counts at 48 kHz and starts after its pre-skip.
+// 用流最后一页的granule位置除以编解码器的采样率，Opus始终以48 kHz计数并从预跳过之后开始。
+func oggDuration(r io.ReadSeeker, fileSize int64) (uint64, error) {
+	first, err := readOggPage(r)
+	if err != nil {
+		return 0, err
+	}
+	var sampleRate, preSkip uint64
+	switch {
+	case bytes.HasPrefix(first.body, []byte("\x01vorbis")) && len(first.body) >= 16:
+		sampleRate = uint64(binary.LittleEndian.Uint32(first.body[12:16]))
+	case bytes.HasPrefix(first.body, []byte("OpusHead")) && len(first.body) >= 12:
+		sampleRate, preSkip = 48000, uint64(binary.LittleEndian.Uint16(first.body[10:12]))
+	default:
+		return 0, fmt.Errorf("unsupported Ogg codec")
+	}
+	if sampleRate == 0 {
+		return 0, fmt.Errorf("the Ogg stream has no sample rate")
+	}
+	//The last page is looked for in the end of the file, pages are at most about 64 KiB.
+	//在文件末尾查找最后一页，页的大小最多约为64 KiB。
+	tailSize := min(fileSize, 65536+27+255)
+	tail := make([]byte, tailSize)
+	if _, err := r.Seek(fileSize-tailSize, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.ReadFull(r, tail); err != nil {
+		return 0, err
+	}
+	for i := bytes.LastIndex(tail, []byte("OggS")); i >= 0; i = bytes.LastIndex(tail[:i], []byte("OggS")) {
+		if len(tail)-i < 27 {
+			continue
+		}
+		granule := binary.LittleEndian.Uint64(tail[i+6 : i+14])
+		serial := binary.LittleEndian.Uint32(tail[i+14 : i+18])
+		if serial != first.serial || granule == ^uint64(0) {
+			continue
+		}
+		if granule < preSkip {
+			return 0, nil
+		}
+		return (granule - preSkip) * 1_000_000 / sampleRate, nil
+	}
+	return 0, fmt.Errorf("the Ogg stream has no last page")
+}
+
+// mp4Duration Read the time scale and the duration of the movie header atom.
+// 读取影片头部原子的时间刻度和时长。
+func mp4Duration(r io.ReadSeeker) (uint64, error) {
+	moov, err := readMP4Moov(r)
+	if err != nil {
+		return 0, err
+	}
+	mvhd := mp4Atom(moov, "mvhd")
+	var timeScale, duration uint64
+	switch {
+	case len(mvhd) >= 20 && mvhd[0] == 0:
+		timeScale, duration = uint64(binary.BigEndian.Uint32(mvhd[12:16])), uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	case len(mvhd) >= 32 && mvhd[0] == 1:
+		timeScale, duration = uint64(binary.BigEndian.Uint32(mvhd[20:24])), binary.BigEndian.Uint64(mvhd[24:32])
+	default:
+		return 0, fmt.Errorf("the MP4 file has no movie header")
+	}
+	if timeScale == 0 {
+		return 0, fmt.Errorf("the MP4 movie header has no time scale")
+	}
+	return duration * 1_000_000 / timeScale, nil
+}
+
+// mpegDuration Find the first MPEG audio frame after the ID3v2 tag and compute the duration of an MP3 or ADTS AAC
+// stream from it.
+// 找到ID3v2标签之后的第一个MPEG音频帧，并据此计算MP3或ADTS AAC流的时长。
+func mpegDuration(r *os.File, fileSize int64) (uint64, error) {
+	start := int64(0)
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err == nil && bytes.HasPrefix(header, []byte("ID3")) {
+		start = 10 + int64(syncsafe(header[6:10]))
+		if header[5]&0x10 != 0 {
+			start += 10
+		}
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 65536)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && n == 0 {
+		return 0, fmt.Errorf("unsupported audio format")
+	}
+	buf = buf[:n]
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xFF || buf[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		if buf[i+1]&0x06 == 0 {
+			if buf[i+1]&0xF0 == 0xF0 && isADTSFrame(buf[i:]) {
+				return adtsDuration(r, start+int64(i))
+			}
+			continue
+		}
+		if duration, ok := mp3Duration(r, buf[i:], start+int64(i), fileSize); ok {
+			return duration, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported audio format")
+}
+
+// mp3Duration Compute the duration from the number of frames in the Xing, Info or VBRI header of the first frame,
+// otherwise from the bitrate of the first frame. frame holds the data from the frame on, returns false if it does not
+// start with a valid frame.
+// 根据第一帧中Xing、Info或VBRI头部的帧数计算时长，否则根据第一帧的比特率计算。frame包含从该帧开始的数据，不以有效的帧开头时返回false。
+func mp3Duration(r io.ReaderAt, frame []byte, offset, fileSize int64) (uint64, bool) {
+	versionBits, layerBits := frame[1]>>3&0x03, frame[1]>>1&0x03
+	bitrateIndex, sampleRateIndex := frame[2]>>4, frame[2]>>2&0x03
+	if versionBits == 1 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return 0, false
+	}
+	version := 0
+	switch versionBits {
+	case 2:
+		version = 1
+	case 0:
+		version = 2
+	}
+	layer := 3 - int(layerBits)
+	bitrate := mpegBitrates[min(version, 1)][layer][bitrateIndex]
+	sampleRate := mpegSampleRates[version][sampleRateIndex]
+	padding := uint64(frame[2] >> 1 & 0x01)
+	mono := frame[3]>>6 == 3
+	samplesPerFrame := uint64(1152)
+	frameLength := 144*bitrate*1000/sampleRate + padding
+	switch {
+	case layer == 0:
+		samplesPerFrame = 384
+		frameLength = (12*bitrate*1000/sampleRate + padding) * 4
+	case layer == 2 && version > 0:
+		samplesPerFrame = 576
+		frameLength = 72*bitrate*1000/sampleRate + padding
+	}
+	//Two bytes looking like a frame header are common in other data, the next frame has to follow.
+	//看起来像帧头部的两个字节在其他数据中很常见，因此下一帧必须紧随其后。
+	if next := int(frameLength); next != len(frame) && (next+2 > len(frame) || frame[next] != 0xFF || frame[next+1]&0xE0 != 0xE0) {
+		return 0, false
+	}
+	sideInfo := 32
+	switch {
+	case version == 0 && mono:
+		sideInfo = 17
+	case version > 0 && !mono:
+		sideInfo = 17
+	case version > 0:
+		sideInfo = 9
+	}
+	if xing := 4 + sideInfo; len(frame) >= xing+12 {
+		tag := string(frame[xing : xing+4])
+		if (tag == "Xing" || tag == "Info") && frame[xing+7]&0x01 != 0 {
+			if frames := uint64(binary.BigEndian.Uint32(frame[xing+8 : xing+12])); frames > 0 {
+				return frames * samplesPerFrame * 1_000_000 / sampleRate, true
+			}
+		}
+	}
+	if len(frame) >= 36+18 && string(frame[36:40]) == "VBRI" {
+		if frames := uint64(binary.BigEndian.Uint32(frame[50:54])); frames > 0 {
+			return frames * samplesPerFrame * 1_000_000 / sampleRate, true
+		}
+	}
+	//A constant bitrate stream, the ID3v1 tag at the end is not audio.
+	//恒定比特率的流，末尾的ID3v1标签不是音频。
+	size := fileSize - offset
+	trailer := make([]byte, 3)
+	if _, err := r.ReadAt(trailer, fileSize-128); err == nil && string(trailer) == "TAG" {
+		size -= 128
+	}
+	return uint64(max(size, 0)) * 8 * 1000 / bitrate, true
+}
+
+// isADTSFrame Whether data starts with an ADTS frame that is followed by the next frame or by the end of the data.
+// data是否以ADTS帧开头，且其后紧跟下一帧或数据结尾。
+func isADTSFrame(data []byte) bool {
+	if len(data) < 7 || int(data[2]>>2&0x0F) >= len(adtsSampleRates) {
+		return false
+	}
+	next := int(data[3]&0x03)<<11 | int(data[4])<<3 | int(data[5])>>5
+	if next == len(data) {
+		return true
+	}
+	return next >= 7 && next+2 <= len(data) && data[next] == 0xFF && data[next+1]&0xF6 == 0xF0
+}
+
+// adtsDuration Count the samples of every ADTS frame, each raw data block holds 1024 samples.
+// 统计每个ADTS帧的采样数，每个原始数据块包含1024个采样。
+func adtsDuration(r io.ReadSeeker, offset int64) (uint64, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	header := make([]byte, 7)
+	var samples, sampleRate uint64
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			break
+		}
+		if header[0] != 0xFF || header[1]&0xF6 != 0xF0 {
+			break
+		}
+		index := int(header[2] >> 2 & 0x0F)
+		if index >= len(adtsSampleRates) {
+			break
+		}
+		sampleRate = adtsSampleRates[index]
+		frameLength := int64(header[3]&0x03)<<11 | int64(header[4])<<3 | int64(header[5])>>5
+		if frameLength < 7 {
+			break
+		}
+		samples += uint64(header[6]&0x03+1) * 1024
+		if _, err := r.Seek(frameLength-7, io.SeekCurrent); err != nil {
+			break
+		}
+	}
+	if samples == 0 {
+		return 0, fmt.Errorf("the AAC stream has no frames")
+	}
+	return samples * 1_000_000 / sampleRate, nil
+}
+
+// probeDuration Get the duration with ffprobe.
+// 使用ffprobe获取时长。
+func probeDuration(audioFilePath string) (uint64, error) {
+	// 使用 ffmpeg-go 的 Probe 方法获取音频文件的元数据，返回 JSON 格式的数据
+	output, err := ffmpeggo.Probe(audioFilePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to probe the audio file: %v", err)
+	}
+
+	// 解析 JSON 数据
+	var metadata struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	err = json.Unmarshal([]byte(output), &metadata)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ffmpeg probe output: %v", err)
+	}
+	if metadata.Format.Duration == "" {
+		return 0, fmt.Errorf("ffprobe did not report a duration")
+	}
+
+	// 将时长转换为 float64 类型的秒数
+	duration, err := strconv.ParseFloat(metadata.Format.Duration, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert duration to float: %v", err)
+	}
+
+	// 将时长从秒转换为微秒
+	return uint64(duration * 1_000_000), nil
+}
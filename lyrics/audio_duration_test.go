@@ -0,0 +1,187 @@
+package lyrics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+func TestMP3Duration(t *testing.T) {
+	cbr := slices.Concat(mp3Frame(nil), mp3Frame(nil))
+	tests := []struct {
+		name string
+		data []byte
+		want uint64
+		ok   bool
+	}{
+		{"constant bitrate", cbr, 834 * 8 * 1000 / 128, true},
+		{"constant bitrate before an ID3v1 tag", slices.Concat(cbr, []byte("TAG"), make([]byte, 125)), 834 * 8 * 1000 / 128, true},
+		{"Xing header", mp3Frame(map[int][]byte{36: []byte("Xing"), 43: {0x01}, 44: binary.BigEndian.AppendUint32(nil, 1000)}), 1000 * 1152 * 1_000_000 / 44100, true},
+		{"Info header without a frame count", mp3Frame(map[int][]byte{36: []byte("Info")}), 417 * 8 * 1000 / 128, true},
+		{"VBRI header", mp3Frame(map[int][]byte{36: []byte("VBRI"), 50: binary.BigEndian.AppendUint32(nil, 1000)}), 1000 * 1152 * 1_000_000 / 44100, true},
+		{"no following frame", slices.Concat(mp3Frame(nil), []byte("xx")), 0, false},
+		{"free bitrate", []byte{0xFF, 0xFB, 0x00, 0x00}, 0, false},
+	}
+	for _, tt := range tests {
+		duration, ok := mp3Duration(bytes.NewReader(tt.data), tt.data, 0, int64(len(tt.data)))
+		if ok != tt.ok || duration != tt.want {
+			t.Errorf("%s: duration = %d, %t, want %d, %t", tt.name, duration, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestADTSDuration(t *testing.T) {
+	stream := slices.Concat(adtsFrame(), adtsFrame(), adtsFrame())
+	if !isADTSFrame(stream) {
+		t.Error("isADTSFrame rejected a frame followed by the next frame")
+	}
+	if isADTSFrame(append(adtsFrame(), 1, 2)) {
+		t.Error("isADTSFrame accepted a frame followed by other data")
+	}
+	duration, err := adtsDuration(bytes.NewReader(stream), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(3 * 1024 * 1_000_000 / 44100); duration != want {
+		t.Errorf("duration = %d, want %d", duration, want)
+	}
+}
+
+func TestFLACDuration(t *testing.T) {
+	file := slices.Concat([]byte("fLaC"), flacBlock(4, false, vorbisComment()), flacBlock(0, true, streamInfo(44100, 441000)))
+	duration, err := flacDuration(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration != 10_000_000 {
+		t.Errorf("duration = %d, want %d", duration, 10_000_000)
+	}
+	//Encoders that do not know the length write 0 samples.
+	//不知道长度的编码器写入0个采样。
+	file = slices.Concat([]byte("fLaC"), flacBlock(0, true, streamInfo(44100, 0)))
+	if _, err := flacDuration(bytes.NewReader(file)); err == nil {
+		t.Error("flacDuration accepted a STREAMINFO block without samples")
+	}
+}
+
+func TestOggDuration(t *testing.T) {
+	vorbis := slices.Concat(
+		buildOggPage(1, 0, []byte{30}, slices.Concat([]byte("\x01vorbis"), make([]byte, 5), binary.LittleEndian.AppendUint32(nil, 44100), make([]byte, 14))),
+		buildOggPage(1, 88200, []byte{1}, []byte{0}),
+		//The last page of another stream is not part of the song.
+		//另一个流的最后一页不属于歌曲。
+		buildOggPage(2, 1<<40, []byte{1}, []byte{0}),
+	)
+	opus := slices.Concat(
+		buildOggPage(1, 0, []byte{19}, slices.Concat([]byte("OpusHead\x01\x02"), binary.LittleEndian.AppendUint16(nil, 312), make([]byte, 7))),
+		buildOggPage(1, 48000+312, []byte{1}, []byte{0}),
+	)
+	for name, stream := range map[string][]byte{"Vorbis": vorbis, "Opus": opus} {
+		duration, err := oggDuration(bytes.NewReader(stream), int64(len(stream)))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		want := uint64(2_000_000)
+		if name == "Opus" {
+			want = 1_000_000
+		}
+		if duration != want {
+			t.Errorf("%s duration = %d, want %d", name, duration, want)
+		}
+	}
+}
+
+func TestWAVDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		file []byte
+		want uint64
+	}{
+		{"data after a padded chunk", riff(riffChunk("fmt ", wavFormat(176400)), riffChunk("LIST", []byte{1, 2, 3}), riffChunk("data", make([]byte, 17640))), 100_000},
+		{"streamed without a data size", riff(riffChunk("fmt ", wavFormat(176400)), []byte("data\xff\xff\xff\xff"), make([]byte, 17640)), 100_000},
+	}
+	for _, tt := range tests {
+		duration, err := wavDuration(bytes.NewReader(tt.file), int64(len(tt.file)))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if duration != tt.want {
+			t.Errorf("%s: duration = %d, want %d", tt.name, duration, tt.want)
+		}
+	}
+	if _, err := wavDuration(bytes.NewReader(riff(riffChunk("data", make([]byte, 4)))), 16); err == nil {
+		t.Error("wavDuration accepted data without a fmt chunk")
+	}
+}
+
+func TestMP4Duration(t *testing.T) {
+	ftyp := atom("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	version0 := slices.Concat(ftyp, atom("mdat", make([]byte, 16)),
+		atom("moov", atom("mvhd", make([]byte, 12), binary.BigEndian.AppendUint32(nil, 1000), binary.BigEndian.AppendUint32(nil, 5000))))
+	//Version 1 headers have 64-bit times, the mdat before it has a 64-bit size.
+	//版本1的头部使用64位时间，其前面的mdat使用64位大小。
+	version1 := slices.Concat(ftyp, []byte{0, 0, 0, 1, 'm', 'd', 'a', 't', 0, 0, 0, 0, 0, 0, 0, 20, 1, 2, 3, 4},
+		atom("moov", atom("mvhd", []byte{1, 0, 0, 0}, make([]byte, 16), binary.BigEndian.AppendUint32(nil, 600), binary.BigEndian.AppendUint64(nil, 1800))))
+	for version, file := range [][]byte{version0, version1} {
+		duration, err := mp4Duration(bytes.NewReader(file))
+		if err != nil {
+			t.Errorf("version %d: %v", version, err)
+			continue
+		}
+		if want := []uint64{5_000_000, 3_000_000}[version]; duration != want {
+			t.Errorf("version %d: duration = %d, want %d", version, duration, want)
+		}
+	}
+}
+
+func TestAudioDuration(t *testing.T) {
+	//The MPEG frames are searched for after the ID3v2 tag.
+	//在ID3v2标签之后查找MPEG帧。
+	mp3 := slices.Concat(id3Tag(4, id3Frame(4, "TIT2", append([]byte{3}, "Title"...))), mp3Frame(nil), mp3Frame(nil))
+	duration, err := AudioDuration(writeTempFile(t, mp3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(834 * 8 * 1000 / 128); duration != want {
+		t.Errorf("duration = %d, want %d", duration, want)
+	}
+	//Bytes looking like a frame header without a following frame are not audio.
+	//看起来像帧头部但后面没有下一帧的字节不是音频。
+	if _, err := AudioDuration(writeTempFile(t, slices.Concat([]byte{0xFF, 0xFB, 0x90, 0x00}, bytes.Repeat([]byte{'x'}, 600)))); err == nil {
+		t.Error("AudioDuration accepted a file without MPEG frames")
+	}
+}
+
+// mp3Frame Build an MPEG-1 Layer III frame at 128 kbit/s and 44.1 kHz, which is 417 bytes long, with the given bytes
+// placed at their offsets.
+// 构建一个128 kbit/s、44.1 kHz、长417字节的MPEG-1 Layer III帧，并将给定字节放在其偏移处。
+func mp3Frame(at map[int][]byte) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	for offset, data := range at {
+		copy(frame[offset:], data)
+	}
+	return frame
+}
+
+// adtsFrame Build a 10 byte ADTS frame of AAC LC at 44.1 kHz holding one raw data block.
+// 构建一个10字节、44.1 kHz、包含一个原始数据块的AAC LC ADTS帧。
+func adtsFrame() []byte {
+	return []byte{0xFF, 0xF1, 0x50, 0x80, 0x01, 0x5F, 0xFC, 0, 0, 0}
+}
+
+func streamInfo(sampleRate, samples uint32) []byte {
+	block := make([]byte, 34)
+	block[10], block[11], block[12] = byte(sampleRate>>12), byte(sampleRate>>4), byte(sampleRate<<4)
+	binary.BigEndian.PutUint32(block[14:18], samples)
+	return block
+}
+
+func wavFormat(byteRate uint32) []byte {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint32(format[8:12], byteRate)
+	return format
+}
@@ -0,0 +1,630 @@
+package lyrics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrNoLyrics Returned when no lyrics can be found for a track.
+// 找不到歌曲的歌词时返回。
+var ErrNoLyrics = errors.New("no lyrics found")
+
+// AudioTags The tags read from an audio file.
+// 从音频文件读取的标签。
+type AudioTags struct {
+	Title        string
+	Artist       string
+	Album        string
+	TrackNumber  int
+	Lyrics       string      //Unsynchronized lyrics (ID3 USLT, Vorbis LYRICS/UNSYNCEDLYRICS, MP4 ©lyr), often LRC text. 非同步歌词，常为LRC文本。
+	SyncedLyrics []LyricLine //Synchronized lyrics from the ID3 SYLT frame. 来自ID3 SYLT帧的同步歌词。
+}
+
+// ReadAudioTags Read the tags of an MP3, FLAC, Ogg Vorbis/Opus, M4A or WAV file, the format is detected from the content.
+// Returns ErrNoLyrics for an MP3 file whose ID3 tag has an unsupported version.
+// 读取MP3、FLAC、Ogg Vorbis/Opus、M4A或WAV文件的标签，格式根据内容检测。MP3文件的ID3标签版本不受支持时返回ErrNoLyrics。
+func ReadAudioTags(path string) (*AudioTags, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file *os.File) {
+		err := file.Close()
+		if err != nil {
+
+		}
+	}(file)
+	magic := make([]byte, 12)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return nil, fmt.Errorf("failed to read the file header: %v", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	tags := &AudioTags{}
+	var stream io.ReadSeeker = file
+	var id3Err error
+	if bytes.HasPrefix(magic, []byte("ID3")) {
+		//Some taggers put an ID3 tag before a FLAC or other stream, the tags of the stream follow it.
+		//一些标签工具会在FLAC等流之前写入ID3标签，流自身的标签位于其后。
+		id3Err = readID3(file, tags)
+		start := 10 + int64(syncsafe(magic[6:10]))
+		if magic[5]&0x10 != 0 {
+			start += 10
+		}
+		section := io.NewSectionReader(file, start, math.MaxInt64-start)
+		if _, err := io.ReadFull(section, magic); err != nil {
+			return tags, id3Err
+		}
+		if _, err := section.Seek(0, io.SeekStart); err != nil {
+			return tags, err
+		}
+		stream = section
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte("fLaC")):
+		err = readFLACTags(stream, tags)
+	case bytes.HasPrefix(magic, []byte("OggS")):
+		err = readOggTags(stream, tags)
+	case bytes.Equal(magic[4:8], []byte("ftyp")):
+		err = readMP4Tags(stream, tags)
+	case bytes.HasPrefix(magic, []byte("RIFF")) && bytes.Equal(magic[8:12], []byte("WAVE")):
+		err = readWAVTags(stream, tags)
+	default:
+		return tags, id3Err
+	}
+	return tags, err
+}
+
+// NewLyricFromAudioTags Create the lyrics object from the lyrics embedded in the tags of the audio file.
+// Synchronized SYLT lyrics are preferred, unsynchronized lyrics are parsed as LRC. Returns ErrNoLyrics if there are none.
+// 根据音频文件标签中内嵌的歌词创建歌词对象。优先使用同步的SYLT歌词，非同步歌词按LRC解析。没有歌词时返回ErrNoLyrics。
+func NewLyricFromAudioTags(audioPath string, duration uint64, options ParseOptions) (*Lyric, []Diagnostic, error) {
+	tags, err := ReadAudioTags(audioPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tags.SyncedLyrics) > 0 {
+		return &Lyric{Lines: tags.SyncedLyrics, Duration: duration, Metadata: Metadata{Title: tags.Title, Artist: tags.Artist, Album: tags.Album}}, nil, nil
+	}
+	if strings.TrimSpace(tags.Lyrics) == "" {
+		return nil, nil, ErrNoLyrics
+	}
+	//Tag text is already decoded, so the encoding override for lyric files does not apply.
+	//标签文本已经解码，因此歌词文件的编码设置不适用。
+	options.Encoding = "utf-8"
+	options.Format = ".lrc"
+	lyric, diagnostics, err := ParseLyric(strings.NewReader(tags.Lyrics), audioPath, duration, options)
+	if err != nil {
+		return nil, diagnostics, err
+	}
+	if len(lyric.Lines) == 0 {
+		return nil, diagnostics, ErrNoLyrics
+	}
+	return lyric, diagnostics, nil
+}
+
+// readID3 Read an ID3v2.3 or ID3v2.4 tag at the current position of the reader, other versions return ErrNoLyrics.
+// 读取读取器当前位置的ID3v2.3或ID3v2.4标签，其他版本返回ErrNoLyrics。
+func readID3(r io.Reader, tags *AudioTags) error {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	version, flags := header[3], header[5]
+	if version < 3 || version > 4 {
+		return fmt.Errorf("unsupported ID3v2.%d tag: %w", version, ErrNoLyrics)
+	}
+	data, err := readSized(r, int64(syncsafe(header[6:10])))
+	if err != nil {
+		return fmt.Errorf("truncated ID3 tag: %v", err)
+	}
+	if version == 3 && flags&0x80 != 0 {
+		data = removeUnsynchronisation(data)
+	}
+	if flags&0x40 != 0 && len(data) >= 4 {
+		//Skip the extended header, its size includes itself in v2.4 but not in v2.3.
+		//跳过扩展头部，v2.4中其大小包含自身，v2.3中则不包含。
+		size := int(binary.BigEndian.Uint32(data[:4])) + 4
+		if version == 4 {
+			size = syncsafe(data[:4])
+		}
+		data = data[min(size, len(data)):]
+	}
+	for len(data) >= 10 && data[0] != 0 {
+		id := string(data[:4])
+		size := int(binary.BigEndian.Uint32(data[4:8]))
+		if version == 4 {
+			size = syncsafe(data[4:8])
+		}
+		formatFlags := data[9]
+		if size > len(data)-10 {
+			break
+		}
+		frame := data[10 : 10+size]
+		data = data[10+size:]
+		if version == 4 {
+			if formatFlags&0x01 != 0 && len(frame) >= 4 {
+				frame = frame[4:]
+			}
+			if formatFlags&0x02 != 0 || flags&0x80 != 0 {
+				frame = removeUnsynchronisation(frame)
+			}
+		}
+		if len(frame) == 0 {
+			continue
+		}
+		switch id {
+		case "TIT2":
+			tags.Title = decodeID3Text(frame[0], frame[1:])
+		case "TPE1":
+			tags.Artist = decodeID3Text(frame[0], frame[1:])
+		case "TALB":
+			tags.Album = decodeID3Text(frame[0], frame[1:])
+		case "TRCK":
+			number, _, _ := strings.Cut(decodeID3Text(frame[0], frame[1:]), "/")
+			tags.TrackNumber, _ = strconv.Atoi(strings.TrimSpace(number))
+		case "USLT":
+			if len(frame) < 4 || tags.Lyrics != "" {
+				continue
+			}
+			_, text := splitID3String(frame[0], frame[4:])
+			tags.Lyrics = decodeID3Text(frame[0], text)
+		case "SYLT":
+			if len(tags.SyncedLyrics) == 0 {
+				tags.SyncedLyrics = parseSYLT(frame)
+			}
+		}
+	}
+	return nil
+}
+
+// parseSYLT Parse a synchronized lyrics frame, only timestamps in milliseconds are supported.
+// Some taggers store one syllable per entry and start each lyric line with a new line, others store one line per entry.
+// 解析同步歌词帧，仅支持以毫秒为单位的时间戳。部分标签工具每项存储一个音节并以换行开始每行歌词，其他工具每项存储一行。
+func parseSYLT(frame []byte) []LyricLine {
+	if len(frame) < 6 || frame[4] != 2 {
+		return nil
+	}
+	encoding := frame[0]
+	_, rest := splitID3String(encoding, frame[6:])
+	var words []LyricWord
+	syllables := false
+	for len(rest) > 0 {
+		text, after := splitID3String(encoding, rest)
+		if len(after) < 4 {
+			break
+		}
+		word := LyricWord{TimeUs: uint64(binary.BigEndian.Uint32(after[:4])) * 1000, Text: decodeID3Text(encoding, text)}
+		rest = after[4:]
+		if len(words) > 0 && strings.TrimLeft(word.Text, "\r\n") != word.Text {
+			syllables = true
+		}
+		words = append(words, word)
+	}
+	var lines []LyricLine
+	for _, word := range words {
+		newLine := strings.TrimLeft(word.Text, "\r\n") != word.Text
+		word.Text = strings.Trim(word.Text, "\r\n")
+		if syllables && !newLine && len(lines) > 0 {
+			last := &lines[len(lines)-1]
+			last.Words = append(last.Words, word)
+			continue
+		}
+		lines = append(lines, LyricLine{TimeUs: word.TimeUs, Words: []LyricWord{word}})
+	}
+	var result []LyricLine
+	for _, line := range lines {
+		line.Words = trimWords(line.Words)
+		if len(line.Words) == 0 {
+			continue
+		}
+		line.Text = joinWords(line.Words)
+		if !syllables {
+			line.Words = nil
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+// splitID3String Split a terminated string off the start of the data, the terminator depends on the text encoding.
+// 从数据开头分离出一个带终止符的字符串，终止符取决于文本编码。
+func splitID3String(encoding byte, data []byte) ([]byte, []byte) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return data[:i], data[i+2:]
+			}
+		}
+		return data, nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return data[:i], data[i+1:]
+	}
+	return data, nil
+}
+
+// decodeID3Text Decode ID3 text in ISO-8859-1 (0), UTF-16 with BOM (1), UTF-16BE (2) or UTF-8 (3).
+// 解码ISO-8859-1(0)、带BOM的UTF-16(1)、UTF-16BE(2)或UTF-8(3)编码的ID3文本。
+func decodeID3Text(encoding byte, data []byte) string {
+	switch encoding {
+	case 0:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.TrimRight(string(runes), "\x00")
+	case 1, 2:
+		order := binary.ByteOrder(binary.BigEndian)
+		if encoding == 1 && len(data) >= 2 {
+			if data[0] == 0xFF && data[1] == 0xFE {
+				order = binary.LittleEndian
+			}
+			if (data[0] == 0xFF && data[1] == 0xFE) || (data[0] == 0xFE && data[1] == 0xFF) {
+				data = data[2:]
+			}
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[i*2:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	default:
+		return strings.TrimRight(string(data), "\x00")
+	}
+}
+
+// syncsafe Decode a 28-bit syncsafe integer.
+// 解码28位的同步安全整数。
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// removeUnsynchronisation Undo the ID3 unsynchronisation scheme, which inserts 0x00 after every 0xFF.
+// 撤销ID3的非同步化处理，该处理在每个0xFF之后插入0x00。
+func removeUnsynchronisation(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xFF, 0x00}, []byte{0xFF})
+}
+
+// readFLACTags Read the Vorbis comment block of a FLAC file.
+// 读取FLAC文件的Vorbis注释块。
+func readFLACTags(r io.ReadSeeker, tags *AudioTags) error {
+	return readFLACBlocks(r, func(blockType byte, block []byte) {
+		if blockType == 4 {
+			readVorbisComment(block, tags)
+		}
+	}, 4)
+}
+
+// readFLACBlocks Call handle with the content of each metadata block of a FLAC file whose type is wanted, other blocks are skipped.
+// 对FLAC文件中每个所需类型的元数据块调用handle，跳过其他块。
+func readFLACBlocks(r io.ReadSeeker, handle func(blockType byte, block []byte), wanted ...byte) error {
+	if _, err := r.Seek(4, io.SeekStart); err != nil {
+		return err
+	}
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return fmt.Errorf("truncated FLAC metadata: %v", err)
+		}
+		blockType := header[0] & 0x7F
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		if bytes.IndexByte(wanted, blockType) >= 0 {
+			block, err := readSized(r, size)
+			if err != nil {
+				return fmt.Errorf("truncated FLAC metadata: %v", err)
+			}
+			handle(blockType, block)
+		} else if _, err := r.Seek(size, io.SeekCurrent); err != nil {
+			return err
+		}
+		if header[0]&0x80 != 0 {
+			return nil
+		}
+	}
+}
+
+// readVorbisComment Read the fields of a Vorbis comment structure (little-endian lengths followed by KEY=value strings).
+// 读取Vorbis注释结构中的字段（小端长度后跟KEY=value字符串）。
+func readVorbisComment(data []byte, tags *AudioTags) {
+	next := func() (string, bool) {
+		if len(data) < 4 {
+			return "", false
+		}
+		size := binary.LittleEndian.Uint32(data[:4])
+		if uint64(size) > uint64(len(data)-4) {
+			return "", false
+		}
+		value := string(data[4 : 4+size])
+		data = data[4+size:]
+		return value, true
+	}
+	if _, ok := next(); !ok {
+		return
+	}
+	if len(data) < 4 {
+		return
+	}
+	count := binary.LittleEndian.Uint32(data[:4])
+	data = data[4:]
+	for i := uint32(0); i < count; i++ {
+		field, ok := next()
+		if !ok {
+			return
+		}
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			tags.Title = value
+		case "ARTIST":
+			if tags.Artist == "" {
+				tags.Artist = value
+			}
+		case "ALBUM":
+			tags.Album = value
+		case "TRACKNUMBER":
+			number, _, _ := strings.Cut(value, "/")
+			tags.TrackNumber, _ = strconv.Atoi(strings.TrimSpace(number))
+		case "LYRICS":
+			tags.Lyrics = value
+		case "UNSYNCEDLYRICS":
+			if tags.Lyrics == "" {
+				tags.Lyrics = value
+			}
+		}
+	}
+}
+
+// readOggTags Read the comment header of an Ogg Vorbis or Ogg Opus file, which is the second packet of the stream.
+// 读取Ogg Vorbis或Ogg Opus文件的注释头，即流中的第二个数据包。
+func readOggTags(r io.Reader, tags *AudioTags) error {
+	packets, err := readOggPackets(r, 2)
+	if err != nil {
+		return err
+	}
+	if len(packets) < 2 {
+		return fmt.Errorf("the Ogg stream has no comment header")
+	}
+	comment := packets[1]
+	switch {
+	case bytes.HasPrefix(comment, []byte("\x03vorbis")):
+		readVorbisComment(comment[7:], tags)
+	case bytes.HasPrefix(comment, []byte("OpusTags")):
+		readVorbisComment(comment[8:], tags)
+	default:
+		return fmt.Errorf("unsupported Ogg codec")
+	}
+	return nil
+}
+
+// oggPage The header fields of an Ogg page used here.
+// 此处使用的Ogg页头部字段。
+type oggPage struct {
+	granule  uint64
+	serial   uint32
+	segments []byte
+	body     []byte
+}
+
+// readOggPage Read the next page of an Ogg stream.
+// 读取Ogg流的下一页。
+func readOggPage(r io.Reader) (*oggPage, error) {
+	header := make([]byte, 27)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(header, []byte("OggS")) {
+		return nil, fmt.Errorf("lost Ogg page synchronisation")
+	}
+	page := &oggPage{granule: binary.LittleEndian.Uint64(header[6:14]), serial: binary.LittleEndian.Uint32(header[14:18])}
+	page.segments = make([]byte, header[26])
+	if _, err := io.ReadFull(r, page.segments); err != nil {
+		return nil, err
+	}
+	size := 0
+	for _, s := range page.segments {
+		size += int(s)
+	}
+	body, err := readSized(r, int64(size))
+	if err != nil {
+		return nil, err
+	}
+	page.body = body
+	return page, nil
+}
+
+// readOggPackets Read the first count packets of the first logical stream of an Ogg file.
+// 读取Ogg文件第一个逻辑流的前count个数据包。
+func readOggPackets(r io.Reader, count int) ([][]byte, error) {
+	var packets [][]byte
+	var current []byte
+	var serial uint32
+	first := true
+	for len(packets) < count {
+		page, err := readOggPage(r)
+		if err != nil {
+			return packets, fmt.Errorf("failed to read Ogg page: %v", err)
+		}
+		if first {
+			serial, first = page.serial, false
+		} else if page.serial != serial {
+			continue
+		}
+		offset := 0
+		for _, s := range page.segments {
+			current = append(current, page.body[offset:offset+int(s)]...)
+			offset += int(s)
+			//A segment shorter than 255 bytes ends the packet.
+			//短于255字节的段结束数据包。
+			if s < 255 {
+				packets = append(packets, current)
+				current = nil
+				if len(packets) == count {
+					break
+				}
+			}
+		}
+	}
+	return packets, nil
+}
+
+// mp4Atom Find the child atom with the given type among the atoms in data, returns its content.
+// 在data中的原子中查找指定类型的子原子，返回其内容。
+func mp4Atom(data []byte, name string) []byte {
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data[:4]))
+		header := 8
+		if size == 1 && len(data) >= 16 {
+			size, header = int(binary.BigEndian.Uint64(data[8:16])), 16
+		} else if size == 0 {
+			size = len(data)
+		}
+		if size < header || size > len(data) {
+			return nil
+		}
+		if string(data[4:8]) == name {
+			return data[header:size]
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+// readMP4Moov Find the moov atom at the top level of an MP4 file and read it.
+// 在MP4文件的顶层查找moov原子并读取它。
+func readMP4Moov(r io.ReadSeeker) ([]byte, error) {
+	header := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, fmt.Errorf("the MP4 file has no moov atom")
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+		if size == 1 {
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, err
+			}
+			size, headerSize = int64(binary.BigEndian.Uint64(header[8:16])), 16
+		}
+		if size < headerSize {
+			return nil, fmt.Errorf("invalid MP4 atom size")
+		}
+		if string(header[4:8]) == "moov" {
+			moov, err := readSized(r, size-headerSize)
+			if err != nil {
+				return nil, fmt.Errorf("truncated moov atom: %v", err)
+			}
+			return moov, nil
+		}
+		if _, err := r.Seek(size-headerSize, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readMP4Tags Read the iTunes metadata (moov/udta/meta/ilst) of an MP4 file.
+// 读取MP4文件的iTunes元数据(moov/udta/meta/ilst)。
+func readMP4Tags(r io.ReadSeeker, tags *AudioTags) error {
+	moov, err := readMP4Moov(r)
+	if err != nil {
+		return err
+	}
+	meta := mp4Atom(mp4Atom(moov, "udta"), "meta")
+	if len(meta) < 4 {
+		return nil
+	}
+	//meta is a full atom, its children follow the version and flags.
+	//meta是完整原子，其子原子位于版本和标志之后。
+	ilst := mp4Atom(meta[4:], "ilst")
+	value := func(name string) []byte {
+		data := mp4Atom(mp4Atom(ilst, name), "data")
+		if len(data) < 8 {
+			return nil
+		}
+		return data[8:]
+	}
+	tags.Title = string(value("\xa9nam"))
+	tags.Artist = string(value("\xa9ART"))
+	tags.Album = string(value("\xa9alb"))
+	tags.Lyrics = string(value("\xa9lyr"))
+	if track := value("trkn"); len(track) >= 4 {
+		tags.TrackNumber = int(binary.BigEndian.Uint16(track[2:4]))
+	}
+	return nil
+}
+
+// readSized Read size bytes, where size comes from the file. The size is checked against the bytes left before they
+// are allocated, so a corrupt size returns an error instead of allocating gigabytes.
+// 读取size个字节，size来自文件。分配前会将size与剩余字节数比较，因此损坏的大小会返回错误而不是分配数GB的内存。
+func readSized(r io.Reader, size int64) ([]byte, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("invalid size %d", size)
+	}
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		//Without seeking the bytes left are unknown, the buffer only grows with the bytes actually read.
+		//无法定位时剩余字节数未知，缓冲区只随实际读取的字节增长。
+		data, err := io.ReadAll(io.LimitReader(r, size))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) < size {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return data, nil
+	}
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if size > end-current {
+		return nil, fmt.Errorf("%d bytes are needed but only %d are left", size, end-current)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readWAVTags Read the ID3 tag stored in the "id3 " chunk of a WAV file.
+// 读取存储在WAV文件"id3 "块中的ID3标签。
+func readWAVTags(r io.ReadSeeker, tags *AudioTags) error {
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return err
+	}
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil
+		}
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		if strings.EqualFold(string(header[:4]), "id3 ") {
+			return readID3(io.LimitReader(r, size), tags)
+		}
+		//Chunks are padded to an even size.
+		//块会被填充到偶数大小。
+		if _, err := r.Seek(size+size%2, io.SeekCurrent); err != nil {
+			return err
+		}
+	}
+}
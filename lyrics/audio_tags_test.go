@@ -0,0 +1,322 @@
+package lyrics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+func TestCorruptSizes(t *testing.T) {
+	//A moov atom with a 64-bit size of 1TB in a file of a few bytes.
+	//在只有几个字节的文件中声明64位大小为1TB的moov原子。
+	moov := []byte{0, 0, 0, 1, 'm', 'o', 'o', 'v', 0, 0, 1, 0, 0, 0, 0, 0, 1, 2, 3}
+	if _, err := readMP4Moov(bytes.NewReader(moov)); err == nil {
+		t.Error("readMP4Moov accepted a moov atom larger than the file")
+	}
+	//A 64-bit size that is negative as int64.
+	//作为int64为负数的64位大小。
+	binary.BigEndian.PutUint64(moov[8:16], 1<<63+16)
+	if _, err := readMP4Moov(bytes.NewReader(moov)); err == nil {
+		t.Error("readMP4Moov accepted a negative atom size")
+	}
+	flac := []byte{'f', 'L', 'a', 'C', 0x84, 0xFF, 0xFF, 0xFF, 1, 2, 3}
+	if err := readFLACTags(bytes.NewReader(flac), &AudioTags{}); err == nil {
+		t.Error("readFLACTags accepted a block larger than the file")
+	}
+	id3 := []byte{'I', 'D', '3', 4, 0, 0, 0x7F, 0x7F, 0x7F, 0x7F, 1, 2, 3}
+	if err := readID3(bytes.NewReader(id3), &AudioTags{}); err == nil {
+		t.Error("readID3 accepted a tag larger than the file")
+	}
+	if err := readID3(iotest.OneByteReader(bytes.NewReader(id3)), &AudioTags{}); err == nil {
+		t.Error("readID3 accepted a tag larger than the stream")
+	}
+}
+
+func TestReadID3(t *testing.T) {
+	utf16Lyrics := strings.Repeat("[00:01.00]歌词 ", 20)
+	tests := []struct {
+		name string
+		tag  []byte
+		want AudioTags
+	}{
+		{
+			name: "ID3v2.4 text frames and USLT",
+			tag: id3Tag(4,
+				id3Frame(4, "TIT2", append([]byte{3}, "Title"...)),
+				id3Frame(4, "TPE1", append([]byte{0}, "Art\xe9"...)),
+				id3Frame(4, "TRCK", append([]byte{3}, "3/12"...)),
+				id3Frame(4, "USLT", append([]byte{3, 'e', 'n', 'g', 0}, "[00:01.00]Hello"...)),
+			),
+			want: AudioTags{Title: "Title", Artist: "Arté", TrackNumber: 3, Lyrics: "[00:01.00]Hello"},
+		},
+		{
+			//The frame is longer than 127 bytes, so reading its size as syncsafe would lose the frame.
+			//帧长于127字节，按同步安全整数读取其大小会丢失该帧。
+			name: "ID3v2.3 USLT in UTF-16",
+			tag:  id3Tag(3, id3Frame(3, "USLT", append([]byte{1, 'e', 'n', 'g', 0xFF, 0xFE, 0, 0, 0xFF, 0xFE}, utf16LE(utf16Lyrics)...))),
+			want: AudioTags{Lyrics: utf16Lyrics},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tags AudioTags
+			if err := readID3(bytes.NewReader(tt.tag), &tags); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tags, tt.want) {
+				t.Errorf("tags = %+v, want %+v", tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSYLT(t *testing.T) {
+	//Taggers storing one syllable per entry start each line with a new line.
+	//每项存储一个音节的标签工具以换行开始每一行。
+	syllables := parseSYLT(syltFrame("Hel", 1000, "lo", 1500, "\nWorld", 3000))
+	want := []LyricLine{
+		{TimeUs: 1_000_000, Text: "Hello", Words: []LyricWord{{TimeUs: 1_000_000, Text: "Hel"}, {TimeUs: 1_500_000, Text: "lo"}}},
+		{TimeUs: 3_000_000, Text: "World", Words: []LyricWord{{TimeUs: 3_000_000, Text: "World"}}},
+	}
+	if !reflect.DeepEqual(syllables, want) {
+		t.Errorf("syllable entries = %+v, want %+v", syllables, want)
+	}
+	lines := parseSYLT(syltFrame("Hello", 1000, "World", 3000))
+	want = []LyricLine{{TimeUs: 1_000_000, Text: "Hello"}, {TimeUs: 3_000_000, Text: "World"}}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("line entries = %+v, want %+v", lines, want)
+	}
+	//Timestamps in MPEG frames are not supported.
+	//不支持以MPEG帧为单位的时间戳。
+	frame := syltFrame("Hello", 1000)
+	frame[4] = 1
+	if lines := parseSYLT(frame); lines != nil {
+		t.Errorf("frame timestamps = %+v, want none", lines)
+	}
+}
+
+func TestReadVorbisComment(t *testing.T) {
+	var tags AudioTags
+	readVorbisComment(vorbisComment("TITLE=Title", "ARTIST=First", "ARTIST=Second", "TRACKNUMBER=7/10", "no separator", "UNSYNCEDLYRICS=unsynced", "lyrics=[00:01.00]Hello"), &tags)
+	want := AudioTags{Title: "Title", Artist: "First", TrackNumber: 7, Lyrics: "[00:01.00]Hello"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %+v, want %+v", tags, want)
+	}
+	//A field longer than the data ends the comment instead of reading past it.
+	//长于数据的字段会结束注释，而不是越界读取。
+	truncated := vorbisComment("TITLE=Title", "ALBUM=Album")
+	tags = AudioTags{}
+	readVorbisComment(truncated[:len(truncated)-2], &tags)
+	if tags.Title != "Title" || tags.Album != "" {
+		t.Errorf("truncated comment = %+v", tags)
+	}
+}
+
+func TestReadOggTags(t *testing.T) {
+	//The comment header spans two pages, with a page of another stream between them.
+	//注释头跨越两页，中间夹着另一个流的页。
+	comment := append([]byte("\x03vorbis"), vorbisComment("TITLE=Title", "LYRICS="+strings.Repeat("a", 300))...)
+	stream := slices.Concat(
+		buildOggPage(1, 0, []byte{30}, append([]byte("\x01vorbis"), make([]byte, 23)...)),
+		buildOggPage(1, 0, []byte{255}, comment[:255]),
+		buildOggPage(2, 0, []byte{4}, []byte("junk")),
+		buildOggPage(1, 0, lacing(len(comment)-255), comment[255:]),
+	)
+	var tags AudioTags
+	if err := readOggTags(bytes.NewReader(stream), &tags); err != nil {
+		t.Fatal(err)
+	}
+	if tags.Title != "Title" || tags.Lyrics != strings.Repeat("a", 300) {
+		t.Errorf("Vorbis tags = %+v", tags)
+	}
+
+	opusTags := append([]byte("OpusTags"), vorbisComment("ALBUM=Album")...)
+	stream = slices.Concat(
+		buildOggPage(1, 0, []byte{19}, append([]byte("OpusHead"), make([]byte, 11)...)),
+		buildOggPage(1, 0, lacing(len(opusTags)), opusTags),
+	)
+	tags = AudioTags{}
+	if err := readOggTags(bytes.NewReader(stream), &tags); err != nil {
+		t.Fatal(err)
+	}
+	if tags.Album != "Album" {
+		t.Errorf("Opus tags = %+v", tags)
+	}
+}
+
+func TestReadMP4Tags(t *testing.T) {
+	text := func(value string) []byte {
+		return atom("data", []byte{0, 0, 0, 1, 0, 0, 0, 0}, []byte(value))
+	}
+	file := slices.Concat(
+		atom("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		atom("free", make([]byte, 5)),
+		atom("moov", atom("udta", atom("meta", []byte{0, 0, 0, 0}, atom("ilst",
+			atom("\xa9nam", text("Title")),
+			atom("trkn", atom("data", make([]byte, 8), []byte{0, 0, 0, 5, 0, 9, 0, 0})),
+			atom("\xa9lyr", text("[00:01.00]Hello")),
+		)))),
+	)
+	var tags AudioTags
+	if err := readMP4Tags(bytes.NewReader(file), &tags); err != nil {
+		t.Fatal(err)
+	}
+	want := AudioTags{Title: "Title", TrackNumber: 5, Lyrics: "[00:01.00]Hello"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %+v, want %+v", tags, want)
+	}
+}
+
+func TestReadAudioTags(t *testing.T) {
+	//The container is detected from the content, not from the file name.
+	//容器根据内容而不是文件名检测。
+	flac := slices.Concat([]byte("fLaC"), flacBlock(0, false, make([]byte, 34)), flacBlock(4, true, vorbisComment("ALBUM=Album")))
+	files := map[string][]byte{
+		"FLAC": flac,
+		//The tags of the stream after an ID3 tag are read as well, even if the ID3 version is not supported.
+		//ID3标签之后的流的标签同样会被读取，即使ID3版本不受支持。
+		"FLAC after an ID3v2.4 tag": slices.Concat(id3Tag(4, id3Frame(4, "TIT2", append([]byte{3}, "Title"...))), flac),
+		"FLAC after an ID3v2.2 tag": slices.Concat(id3Tag(2, []byte("TT2\x00\x00\x06\x00Title")), flac),
+		"WAV": riff(
+			riffChunk("fmt ", make([]byte, 16)),
+			riffChunk("LIST", []byte{1, 2, 3}),
+			riffChunk("id3 ", id3Tag(4, id3Frame(4, "TALB", append([]byte{3}, "Album"...)))),
+		),
+	}
+	for name, data := range files {
+		tags, err := ReadAudioTags(writeTempFile(t, data))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if tags.Album != "Album" {
+			t.Errorf("%s album = %q, want %q", name, tags.Album, "Album")
+		}
+	}
+	mp3 := slices.Concat(id3Tag(2, []byte("ULT\x00\x00\x05\x00engHi")), mp3Frame(nil))
+	if _, err := ReadAudioTags(writeTempFile(t, mp3)); !errors.Is(err, ErrNoLyrics) {
+		t.Errorf("got error %v for an ID3v2.2 tag, want ErrNoLyrics", err)
+	}
+}
+
+// writeTempFile Write the data to a file in a temporary directory of the test and return its path.
+// 将数据写入测试临时目录中的文件并返回其路径。
+func writeTempFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audio")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// id3Tag Build an ID3v2 tag of the given version from its frames.
+// 用帧构建指定版本的ID3v2标签。
+func id3Tag(version byte, frames ...[]byte) []byte {
+	data := slices.Concat(frames...)
+	return slices.Concat([]byte{'I', 'D', '3', version, 0, 0}, syncsafeBytes(len(data)), data)
+}
+
+// id3Frame Build an ID3v2 frame, the size is syncsafe in ID3v2.4 only.
+// 构建ID3v2帧，仅在ID3v2.4中大小为同步安全整数。
+func id3Frame(version byte, id string, payload []byte) []byte {
+	size := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	if version == 4 {
+		size = syncsafeBytes(len(payload))
+	}
+	return slices.Concat([]byte(id), size, []byte{0, 0}, payload)
+}
+
+func syncsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
+
+func utf16LE(text string) []byte {
+	var data []byte
+	for _, unit := range utf16.Encode([]rune(text)) {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+	return data
+}
+
+// syltFrame Build a UTF-8 SYLT frame with millisecond timestamps from pairs of text and time.
+// 用文本和时间对构建以毫秒为时间戳的UTF-8 SYLT帧。
+func syltFrame(entries ...any) []byte {
+	frame := []byte{3, 'e', 'n', 'g', 2, 1, 0}
+	for i := 0; i+1 < len(entries); i += 2 {
+		frame = append(frame, entries[i].(string)...)
+		frame = append(frame, 0)
+		frame = binary.BigEndian.AppendUint32(frame, uint32(entries[i+1].(int)))
+	}
+	return frame
+}
+
+func flacBlock(blockType byte, last bool, data []byte) []byte {
+	if last {
+		blockType |= 0x80
+	}
+	return slices.Concat([]byte{blockType, byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}, data)
+}
+
+func vorbisComment(fields ...string) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, 6)
+	data = append(data, "vendor"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(fields)))
+	for _, field := range fields {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(field)))
+		data = append(data, field...)
+	}
+	return data
+}
+
+// buildOggPage Build an Ogg page, the checksum is left empty because it is not verified.
+// 构建Ogg页，由于校验和不会被验证，因此留空。
+func buildOggPage(serial uint32, granule uint64, segments []byte, body []byte) []byte {
+	header := []byte("OggS\x00\x00")
+	header = binary.LittleEndian.AppendUint64(header, granule)
+	header = binary.LittleEndian.AppendUint32(header, serial)
+	header = append(header, make([]byte, 8)...)
+	header = append(header, byte(len(segments)))
+	return slices.Concat(header, segments, body)
+}
+
+// lacing Get the segment table of complete packets with the given sizes.
+// 获取给定大小的完整数据包的段表。
+func lacing(sizes ...int) []byte {
+	var segments []byte
+	for _, size := range sizes {
+		for ; size >= 255; size -= 255 {
+			segments = append(segments, 255)
+		}
+		segments = append(segments, byte(size))
+	}
+	return segments
+}
+
+func atom(name string, children ...[]byte) []byte {
+	data := slices.Concat(children...)
+	return slices.Concat(binary.BigEndian.AppendUint32(nil, uint32(len(data)+8)), []byte(name), data)
+}
+
+func riff(chunks ...[]byte) []byte {
+	data := slices.Concat(chunks...)
+	return slices.Concat([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(data)+4)), []byte("WAVE"), data)
+}
+
+// riffChunk Build a RIFF chunk, padded to an even size.
+// 构建RIFF块，填充到偶数大小。
+func riffChunk(id string, data []byte) []byte {
+	chunk := slices.Concat([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(data))), data)
+	if len(data)%2 != 0 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
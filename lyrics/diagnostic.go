@@ -0,0 +1,127 @@
+package lyrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity The severity of a diagnostic.
+// 诊断信息的严重程度。
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// MarshalText Encode the severity by its name, so it reads well in JSON.
+// 以名称编码严重程度，使其在JSON中易于阅读。
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText Decode the severity from its name.
+// 从名称解码严重程度。
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "info":
+		*s = SeverityInfo
+	case "warning":
+		*s = SeverityWarning
+	case "error":
+		*s = SeverityError
+	default:
+		return fmt.Errorf("unknown severity %q", text)
+	}
+	return nil
+}
+
+// Diagnostic A problem found while parsing a lyric file. Line and Column start from 1, 0 means unknown.
+// 解析歌词文件时发现的问题。行号和列号从1开始，0表示未知。
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Error Format the diagnostic as file:line:column: severity: message, so it can be returned as an error.
+// The position is left out for diagnostics about the whole file.
+// 将诊断信息格式化为 文件:行:列: 严重程度: 消息，使其可以作为错误返回。针对整个文件的诊断信息省略位置。
+func (d Diagnostic) Error() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// ParseOptions Options used when parsing lyrics.
+// 解析歌词时使用的选项。
+type ParseOptions struct {
+	//Stop at the first error and return it, otherwise broken lines are skipped and only reported as diagnostics.
+	//遇到第一个错误时停止并返回该错误，否则跳过有问题的行，仅作为诊断信息报告。
+	Strict bool
+	//The encoding of the lyric file, such as GBK, Big5, Shift_JIS or UTF-16LE. Empty means automatic detection.
+	//歌词文件的编码，例如GBK、Big5、Shift_JIS或UTF-16LE。为空表示自动检测。
+	Encoding string
+	//The format of the lyrics given by its file extension, such as .lrc or .srt. Empty means LRC, or the extension of the file when opened by path.
+	//以文件扩展名表示的歌词格式，例如.lrc或.srt。为空表示LRC，按路径打开文件时则为文件的扩展名。
+	Format string
+}
+
+// lyricParser Holds the state shared by the parsers while reading one lyric file.
+// 保存读取一个歌词文件时解析器共享的状态。
+type lyricParser struct {
+	file        string
+	options     ParseOptions
+	diagnostics []Diagnostic
+}
+
+// report Record a diagnostic. In strict mode an error is returned for diagnostics with SeverityError, which should stop parsing.
+// 记录一条诊断信息。在严格模式下，SeverityError级别的诊断会作为错误返回，此时应停止解析。
+func (p *lyricParser) report(line, column int, severity Severity, format string, args ...any) error {
+	d := Diagnostic{File: p.file, Line: line, Column: column, Severity: severity, Message: fmt.Sprintf(format, args...)}
+	p.diagnostics = append(p.diagnostics, d)
+	if p.options.Strict && severity == SeverityError {
+		return d
+	}
+	return nil
+}
+
+// fail Record an error that makes the whole file unusable and return it, regardless of the strict mode.
+// 记录一个使整个文件无法使用的错误并返回该错误，与是否为严格模式无关。
+func (p *lyricParser) fail(line, column int, format string, args ...any) error {
+	d := Diagnostic{File: p.file, Line: line, Column: column, Severity: SeverityError, Message: fmt.Sprintf(format, args...)}
+	p.diagnostics = append(p.diagnostics, d)
+	return d
+}
+
+// decode Convert the content of the lyric file to UTF-8 and note the encoding if it had to be converted.
+// 将歌词文件的内容转换为UTF-8，若进行了转换则记录所使用的编码。
+func (p *lyricParser) decode(data []byte) (string, error) {
+	text, encodingName, err := decodeText(data, p.options.Encoding)
+	if err != nil {
+		return "", err
+	}
+	if p.options.Encoding == "" && encodingName != "UTF-8" {
+		if err := p.report(0, 0, SeverityInfo, "converted from %s to UTF-8", encodingName); err != nil {
+			return "", err
+		}
+	}
+	return text, nil
+}
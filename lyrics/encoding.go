@@ -0,0 +1,166 @@
+package lyrics
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+)
+
+// legacyEncodings The legacy encodings tried in order when the content is not valid UTF-8.
+// 当内容不是有效的UTF-8时，按顺序尝试的旧编码。
+var legacyEncodings = []struct {
+	name     string
+	encoding encoding.Encoding
+}{
+	{"GB18030", simplifiedchinese.GB18030},
+	{"Big5", traditionalchinese.Big5},
+	{"Shift_JIS", japanese.ShiftJIS},
+}
+
+// commonSimplified and commonTraditional Frequently used characters that only exist in one of the two scripts,
+// used to tell GBK text from Big5 text.
+// 常用的简体字和繁体字，它们只存在于其中一种字体中，用于区分GBK文本和Big5文本。
+const (
+	commonSimplified  = "们个来说这国时过对发后会学长见点开关门问间听让话还没应为实现爱梦风飞远当无与从东车马鸟鱼经给红绿颜边样里电动脑难声恋泪忆亲脸岁灯"
+	commonTraditional = "們個來說這國時過對發後會學長見點開關門問間聽讓話還沒應為實現愛夢風飛遠當無與從東車馬鳥魚經給紅綠顏邊樣裡電動腦難聲戀淚憶親臉歲燈"
+)
+
+// CheckEncoding Check whether the encoding name can be used to decode lyric files, an empty name means automatic detection.
+// 检查编码名称是否可用于解码歌词文件，空名称表示自动检测。
+func CheckEncoding(name string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := htmlindex.Get(name)
+	if err != nil {
+		return fmt.Errorf("unknown encoding %q", name)
+	}
+	return nil
+}
+
+// decodeText Convert the content of a lyric file to UTF-8 and return the name of the encoding used.
+// If name is empty the encoding is detected from the byte order mark or guessed from the content.
+// 将歌词文件的内容转换为UTF-8并返回所使用的编码名称。name为空时，根据字节顺序标记检测编码或根据内容猜测编码。
+func decodeText(data []byte, name string) (string, string, error) {
+	if name != "" {
+		enc, err := htmlindex.Get(name)
+		if err != nil {
+			return "", "", fmt.Errorf("unknown encoding %q", name)
+		}
+		text, err := enc.NewDecoder().Bytes(data)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decode as %s: %v", name, err)
+		}
+		return strings.TrimPrefix(string(text), "\uFEFF"), name, nil
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:]), "UTF-8", nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeWith(data[2:], textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM), "UTF-16LE")
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeWith(data[2:], textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM), "UTF-16BE")
+	}
+	//ASCII in UTF-16 is also valid UTF-8, so UTF-16 has to be ruled out first.
+	//UTF-16中的ASCII同样是有效的UTF-8，因此需要先排除UTF-16。
+	if order, ok := guessUTF16(data); ok {
+		return decodeWith(data, textunicode.UTF16(order, textunicode.IgnoreBOM), utf16Name(order))
+	}
+	if utf8.Valid(data) {
+		return string(data), "UTF-8", nil
+	}
+	bestText, bestName, bestScore := "", "", 0
+	for i, legacy := range legacyEncodings {
+		decoded, err := legacy.encoding.NewDecoder().Bytes(data)
+		if err != nil {
+			continue
+		}
+		text := string(decoded)
+		score := scoreText(text)
+		if i == 0 || score > bestScore {
+			bestText, bestName, bestScore = text, legacy.name, score
+		}
+	}
+	if bestName == "" {
+		return string(data), "UTF-8", nil
+	}
+	return bestText, bestName, nil
+}
+
+func decodeWith(data []byte, enc encoding.Encoding, name string) (string, string, error) {
+	text, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode as %s: %v", name, err)
+	}
+	return string(text), name, nil
+}
+
+func utf16Name(order textunicode.Endianness) string {
+	if order == textunicode.BigEndian {
+		return "UTF-16BE"
+	}
+	return "UTF-16LE"
+}
+
+// guessUTF16 Guess UTF-16 without a byte order mark. LRC files contain many ASCII tags and digits,
+// so in UTF-16 one byte of a large share of the pairs is zero, always at the same side.
+// 猜测没有字节顺序标记的UTF-16。LRC文件包含大量ASCII标签和数字，因此UTF-16中很大一部分字节对的同一侧字节为0。
+func guessUTF16(data []byte) (textunicode.Endianness, bool) {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return textunicode.LittleEndian, false
+	}
+	var evenZeros, oddZeros int
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 {
+			evenZeros++
+		}
+		if data[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	pairs := len(data) / 2
+	switch {
+	case oddZeros*4 > pairs && evenZeros*4 < oddZeros:
+		return textunicode.LittleEndian, true
+	case evenZeros*4 > pairs && oddZeros*4 < evenZeros:
+		return textunicode.BigEndian, true
+	}
+	return textunicode.LittleEndian, false
+}
+
+// scoreText Score how plausible decoded lyrics are. Replacement and control characters are penalized,
+// full-width kana favours Japanese and common simplified or traditional characters favour the matching Chinese encoding.
+// 评估解码后的歌词的合理程度。替换字符和控制字符会被扣分，全角假名有利于日文，常用的简体字或繁体字有利于对应的中文编码。
+func scoreText(text string) int {
+	score := 0
+	for _, r := range text {
+		switch {
+		case r == utf8.RuneError:
+			score -= 20
+		case unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t':
+			score -= 10
+		case unicode.Is(unicode.Co, r):
+			score -= 5
+		case r >= 0xFF61 && r <= 0xFF9F:
+			//Half-width katakana is rare in lyrics but is what most Chinese text looks like when decoded as Shift_JIS.
+			//半角片假名在歌词中很少见，但大多数中文文本按Shift_JIS解码后会变成半角片假名。
+			score -= 2
+		case r >= 0x3040 && r <= 0x30FF:
+			score += 3
+		case strings.ContainsRune(commonSimplified, r), strings.ContainsRune(commonTraditional, r):
+			score += 3
+		case unicode.Is(unicode.Han, r):
+			score++
+		}
+	}
+	return score
+}
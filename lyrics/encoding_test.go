@@ -0,0 +1,95 @@
+package lyrics
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+)
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding encoding.Encoding
+		bom      []byte
+		want     string //The name of the encoding detected. 检测到的编码名称。
+	}{
+		{"GBK", "[00:01.00]我们的梦\n[00:02.00]说过的话\n", simplifiedchinese.GBK, nil, "GB18030"},
+		{"Big5", "[00:01.00]我們的夢\n[00:02.00]說過的話\n", traditionalchinese.Big5, nil, "Big5"},
+		{"Shift_JIS", "[00:01.00]ありがとう\n[00:02.00]さようなら\n", japanese.ShiftJIS, nil, "Shift_JIS"},
+		{"UTF-16LE with a BOM", "[00:01.00]你好\n", textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM), []byte{0xFF, 0xFE}, "UTF-16LE"},
+		{"UTF-16BE with a BOM", "[00:01.00]你好\n", textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM), []byte{0xFE, 0xFF}, "UTF-16BE"},
+		{"UTF-16LE without a BOM", "[00:01.00]你好\n", textunicode.UTF16(textunicode.LittleEndian, textunicode.IgnoreBOM), nil, "UTF-16LE"},
+		{"UTF-16BE without a BOM", "[00:01.00]你好\n", textunicode.UTF16(textunicode.BigEndian, textunicode.IgnoreBOM), nil, "UTF-16BE"},
+		{"UTF-8 with a BOM", "[00:01.00]你好\n", textunicode.UTF8, []byte{0xEF, 0xBB, 0xBF}, "UTF-8"},
+	}
+	for _, tt := range tests {
+		encoded, err := tt.encoding.NewEncoder().String(tt.text)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		text, name, err := decodeText(append(tt.bom, encoded...), "")
+		if err != nil || text != tt.text || name != tt.want {
+			t.Errorf("%s: decodeText = %q, %s, %v, want %q, %s", tt.name, text, name, err, tt.text, tt.want)
+		}
+	}
+}
+
+func TestDecodeTextWithEncoding(t *testing.T) {
+	//The Shift_JIS bytes of the Big5 text decode without errors, the given encoding is used without guessing.
+	//Big5文本的字节按Shift_JIS解码也不会出错，给出编码时直接使用而不猜测。
+	big5, err := traditionalchinese.Big5.NewEncoder().String("[00:01.00]我們的夢\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, name, err := decodeText([]byte(big5), "shift_jis")
+	if err != nil || name != "shift_jis" || strings.Contains(text, "我們") {
+		t.Errorf("decodeText = %q, %s, %v, want Shift_JIS text", text, name, err)
+	}
+	if _, _, err := decodeText([]byte(big5), "no-such-encoding"); err == nil {
+		t.Error("decodeText accepted an unknown encoding")
+	}
+	//The encoding given to the parser is not reported as a conversion.
+	//给解析器指定的编码不会被报告为转换。
+	lyric, diagnostics, err := ParseLyric(strings.NewReader(big5), "test.lrc", 0, ParseOptions{Encoding: "big5"})
+	if err != nil || len(lyric.Lines) != 1 || lyric.Lines[0].Text != "我們的夢" || len(diagnostics) != 0 {
+		t.Errorf("ParseLyric = %+v, %v, %v", lyric, diagnostics, err)
+	}
+}
+
+func TestGuessUTF16(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		order textunicode.Endianness
+		ok    bool
+	}{
+		{"little endian", []byte("[\x000\x000\x00]\x00"), textunicode.LittleEndian, true},
+		{"big endian", []byte("\x00[\x000\x000\x00]"), textunicode.BigEndian, true},
+		{"ASCII", []byte("[00:01.00]"), textunicode.LittleEndian, false},
+		{"odd length", []byte("[\x000\x000"), textunicode.LittleEndian, false},
+		{"too short", []byte("[\x00"), textunicode.LittleEndian, false},
+	}
+	for _, tt := range tests {
+		if order, ok := guessUTF16(tt.data); order != tt.order || ok != tt.ok {
+			t.Errorf("%s: guessUTF16 = %v, %t, want %v, %t", tt.name, order, ok, tt.order, tt.ok)
+		}
+	}
+}
+
+func TestScoreText(t *testing.T) {
+	if scoreText("ありがとう") <= scoreText("ｱﾘｶﾞﾄｳ") {
+		t.Error("kana scored lower than half-width katakana")
+	}
+	if scoreText("我们的梦") <= scoreText("我�的�") {
+		t.Error("common characters scored lower than replacement characters")
+	}
+	if scoreText("a\x01b") >= scoreText("a\nb") {
+		t.Error("a control character scored as high as a line break")
+	}
+}
@@ -0,0 +1,324 @@
+package lyrics
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// karaokeStyle Describes how a karaoke format with per-character timing writes its word tags.
+// 描述一种逐字计时的卡拉OK格式如何书写其字标签。
+type karaokeStyle struct {
+	name        string
+	wordTag     *regexp.Regexp
+	tagAfter    bool //The tag follows the text of its word instead of preceding it. 标签位于其字文本之后而不是之前。
+	relative    bool //Word times are relative to the start of the line. 字时间相对于行的开始时间。
+	jsonCredits bool //Lines in JSON hold credits, such as the lyricist. 以JSON表示的行为制作信息，例如作词。
+}
+
+var (
+	karaokeLineRegex = regexp.MustCompile(`^\[(\d+),(\d+)]`)
+	yrcStyle         = karaokeStyle{name: "YRC", wordTag: regexp.MustCompile(`\((\d+),(\d+),\d+\)`), jsonCredits: true}
+	qrcStyle         = karaokeStyle{name: "QRC", wordTag: regexp.MustCompile(`\((\d+),(\d+)\)`), tagAfter: true}
+	krcStyle         = karaokeStyle{name: "KRC", wordTag: regexp.MustCompile(`<(\d+),(\d+),\d+>`), relative: true}
+	//The key KRC files are obfuscated with before being compressed.
+	//KRC文件压缩前用于混淆的密钥。
+	krcKey = []byte{64, 71, 97, 119, 94, 50, 116, 71, 81, 54, 49, 45, 206, 210, 110, 105}
+)
+
+// parseYRC Parse lyrics in the NetEase Cloud Music .yrc format.
+// 解析网易云音乐.yrc格式的歌词。
+func parseYRC(p *lyricParser, data []byte, duration uint64) (*Lyric, error) {
+	text, err := p.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	lyric, _, err := parseKaraoke(p, text, duration, yrcStyle)
+	return lyric, err
+}
+
+// parseQRC Parse lyrics in the QQ Music .qrc format, either as plain text or wrapped in the QrcInfos XML document.
+// Encrypted QRC files downloaded by the client have to be decrypted first.
+// 解析QQ音乐.qrc格式的歌词，可以是纯文本，也可以包裹在QrcInfos XML文档中。客户端下载的加密QRC文件需要先解密。
+func parseQRC(p *lyricParser, data []byte, duration uint64) (*Lyric, error) {
+	text, err := p.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "<") {
+		text, err = qrcContent(trimmed)
+		if err != nil {
+			return nil, p.fail(0, 0, "invalid QRC document: %v", err)
+		}
+	} else if !strings.HasPrefix(trimmed, "[") {
+		return nil, p.fail(0, 0, "the QRC file looks encrypted, only decrypted QRC files are supported")
+	}
+	lyric, _, err := parseKaraoke(p, text, duration, qrcStyle)
+	return lyric, err
+}
+
+// qrcContent Get the lyrics in the LyricContent attribute of the QrcInfos XML document.
+// 获取QrcInfos XML文档中LyricContent属性内的歌词。
+func qrcContent(document string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", fmt.Errorf("no LyricContent attribute")
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "LyricContent" {
+					return attr.Value, nil
+				}
+			}
+		}
+	}
+}
+
+// parseKRC Parse lyrics in the Kugou Music .krc format. The file is obfuscated and compressed,
+// its [language:] tag holds the translation and romanization encoded as base64 JSON.
+// 解析酷狗音乐.krc格式的歌词。文件经过混淆和压缩，其[language:]标签包含以base64 JSON编码的翻译和罗马音。
+func parseKRC(p *lyricParser, data []byte, duration uint64) (*Lyric, error) {
+	if bytes.HasPrefix(data, []byte("krc1")) {
+		var err error
+		data, err = decryptKRC(data[4:])
+		if err != nil {
+			return nil, p.fail(0, 0, "invalid KRC container: %v", err)
+		}
+	}
+	text, err := p.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	lyric, tags, err := parseKaraoke(p, text, duration, krcStyle)
+	if err != nil {
+		return nil, err
+	}
+	if language, ok := tags["language"]; ok && language != "" {
+		if err := applyKRCLanguage(lyric, language); err != nil {
+			if err := p.report(0, 0, SeverityWarning, "invalid [language:] tag: %v", err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return lyric, nil
+}
+
+// decryptKRC Undo the XOR obfuscation of a KRC file without its krc1 header and decompress it.
+// 撤销去掉krc1头部的KRC文件的异或混淆并解压。
+func decryptKRC(data []byte) ([]byte, error) {
+	plain := make([]byte, len(data))
+	for i, b := range data {
+		plain[i] = b ^ krcKey[i%len(krcKey)]
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return nil, err
+	}
+	defer func(reader io.ReadCloser) {
+		err := reader.Close()
+		if err != nil {
+
+		}
+	}(reader)
+	return io.ReadAll(reader)
+}
+
+// applyKRCLanguage Attach the translation (type 1, one entry per line) and the romanization (type 0, one entry per word)
+// of the KRC [language:] tag to the lines, which are matched by their order.
+// 将KRC [language:]标签中的翻译（类型1，每行一项）和罗马音（类型0，每字一项）按顺序附加到各行。
+func applyKRCLanguage(lyric *Lyric, encoded string) error {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	var language struct {
+		Content []struct {
+			Type         int        `json:"type"`
+			LyricContent [][]string `json:"lyricContent"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &language); err != nil {
+		return err
+	}
+	for _, content := range language.Content {
+		for i, parts := range content.LyricContent {
+			if i >= len(lyric.Lines) {
+				break
+			}
+			switch content.Type {
+			case 1:
+				lyric.Lines[i].Translation = strings.TrimSpace(strings.Join(parts, ""))
+			case 0:
+				lyric.Lines[i].Romanization = strings.TrimSpace(strings.Join(parts, " "))
+			}
+		}
+	}
+	return nil
+}
+
+// parseKaraoke Parse the lines of a karaoke format in the given style, ID tags are returned by their lower-case names.
+// 按给定的风格解析卡拉OK格式的行，ID标签按小写名称返回。
+func parseKaraoke(p *lyricParser, text string, duration uint64, style karaokeStyle) (*Lyric, map[string]string, error) {
+	var lines []LyricLine
+	var metadata Metadata
+	tags := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(nil, 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if style.jsonCredits && strings.HasPrefix(line, "{") {
+			credit, err := parseYRCCredit(line)
+			if err != nil {
+				if err := p.report(lineNo, 1, SeverityWarning, "invalid credit line: %v", err); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+			lines = append(lines, credit)
+			continue
+		}
+		header := karaokeLineRegex.FindStringSubmatch(line)
+		if header == nil {
+			if idTag := idTagRegex.FindStringSubmatch(line); idTag != nil {
+				metadata.set(idTag[1], strings.TrimSpace(idTag[2]))
+				tags[strings.ToLower(idTag[1])] = strings.TrimSpace(idTag[2])
+				continue
+			}
+			if err := p.report(lineNo, 1, SeverityWarning, "line has no [start,duration] header and is ignored"); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		startMs, err := parseKaraokeMs(header[1])
+		var durationMs uint64
+		if err == nil {
+			durationMs, err = parseKaraokeMs(header[2])
+		}
+		if err != nil {
+			if err := p.report(lineNo, 1, SeverityError, "invalid line time %s: %v", header[0], err); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		lyricLine := LyricLine{TimeUs: startMs * 1000, EndUs: (startMs + durationMs) * 1000}
+		words, badTag, err := style.parseWords(line[len(header[0]):], startMs)
+		if err != nil {
+			if err := p.report(lineNo, columnOf(line, strings.Index(line, badTag)), SeverityError, "invalid word tag %s: %v", badTag, err); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		lyricLine.Words = words
+		if len(lyricLine.Words) == 0 {
+			if err := p.report(lineNo, len(header[0])+1, SeverityWarning, "line has no %s word tags", style.name); err != nil {
+				return nil, nil, err
+			}
+			lyricLine.Text = strings.TrimSpace(style.wordTag.ReplaceAllString(line[len(header[0]):], ""))
+		} else {
+			lyricLine.Words = trimWords(lyricLine.Words)
+			lyricLine.Text = joinWords(lyricLine.Words)
+		}
+		if duration > 0 && lyricLine.TimeUs > duration {
+			if err := p.report(lineNo, 1, SeverityWarning, "line at %s is past the end of the song", formatTimestamp(lyricLine.TimeUs)); err != nil {
+				return nil, nil, err
+			}
+		}
+		lines = append(lines, lyricLine)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if metadata.Offset != 0 {
+		applyOffset(lines, metadata.Offset)
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].TimeUs < lines[j].TimeUs
+	})
+	if duration == 0 {
+		duration = metadata.Length
+	}
+	return &Lyric{Lines: lines, Duration: duration, Metadata: metadata}, tags, nil
+}
+
+// parseWords Split the body of a karaoke line into timed words, startMs is the start of the line.
+// If a word tag has an invalid time, the tag is returned together with the error.
+// 将卡拉OK行的正文拆分为带时间的字，startMs为行的开始时间。字标签的时间无效时，返回该标签及错误。
+func (style karaokeStyle) parseWords(body string, startMs uint64) ([]LyricWord, string, error) {
+	locs := style.wordTag.FindAllStringSubmatchIndex(body, -1)
+	words := make([]LyricWord, 0, len(locs))
+	for i, loc := range locs {
+		wordStart, err := parseKaraokeMs(body[loc[2]:loc[3]])
+		if err != nil {
+			return nil, body[loc[0]:loc[1]], err
+		}
+		wordDuration, err := parseKaraokeMs(body[loc[4]:loc[5]])
+		if err != nil {
+			return nil, body[loc[0]:loc[1]], err
+		}
+		if style.relative {
+			wordStart += startMs
+		}
+		var text string
+		if style.tagAfter {
+			textStart := 0
+			if i > 0 {
+				textStart = locs[i-1][1]
+			}
+			text = body[textStart:loc[0]]
+		} else {
+			textEnd := len(body)
+			if i+1 < len(locs) {
+				textEnd = locs[i+1][0]
+			}
+			text = body[loc[1]:textEnd]
+		}
+		words = append(words, LyricWord{TimeUs: wordStart * 1000, EndUs: (wordStart + wordDuration) * 1000, Text: text})
+	}
+	return words, "", nil
+}
+
+// parseKaraokeMs Parse a time or duration of a karaoke tag in milliseconds. Values are limited to 32 bits, which is
+// more than 49 days, so that adding them and converting them to microseconds cannot overflow.
+// 解析卡拉OK标签中以毫秒为单位的时间或时长。数值限制为32位（超过49天），因此相加并转换为微妙时不会溢出。
+func parseKaraokeMs(value string) (uint64, error) {
+	return strconv.ParseUint(value, 10, 32)
+}
+
+// parseYRCCredit Parse a YRC credit line such as {"t":0,"c":[{"tx":"作词: "},{"tx":"someone"}]}.
+// 解析YRC制作信息行，例如{"t":0,"c":[{"tx":"作词: "},{"tx":"someone"}]}。
+func parseYRCCredit(line string) (LyricLine, error) {
+	var credit struct {
+		T uint64 `json:"t"`
+		C []struct {
+			Tx string `json:"tx"`
+		} `json:"c"`
+	}
+	if err := json.Unmarshal([]byte(line), &credit); err != nil {
+		return LyricLine{}, err
+	}
+	var sb strings.Builder
+	for _, part := range credit.C {
+		sb.WriteString(part.Tx)
+	}
+	return LyricLine{TimeUs: credit.T * 1000, Text: strings.TrimSpace(sb.String())}, nil
+}
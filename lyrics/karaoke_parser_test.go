@@ -0,0 +1,145 @@
+package lyrics
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestKaraokeStyleParseWords(t *testing.T) {
+	//Every style writes the same two syllables of a line starting at 1s: "Hel" from 1s to 1.5s and "lo" from 1.5s to 3s.
+	//每种风格都书写同一行（从1秒开始）的两个音节："Hel"从1秒到1.5秒，"lo"从1.5秒到3秒。
+	want := []LyricWord{{TimeUs: 1_000_000, EndUs: 1_500_000, Text: "Hel"}, {TimeUs: 1_500_000, EndUs: 3_000_000, Text: "lo"}}
+	for style, body := range map[karaokeStyle]string{
+		yrcStyle: "(1000,500,0)Hel(1500,1500,0)lo",
+		qrcStyle: "Hel(1000,500)lo(1500,1500)",
+		krcStyle: "<0,500,0>Hel<500,1500,0>lo",
+	} {
+		if words, _, err := style.parseWords(body, 1000); err != nil || !slices.Equal(words, want) {
+			t.Errorf("%s words = %v, %v, want %v", style.name, words, err, want)
+		}
+	}
+	if _, badTag, err := yrcStyle.parseWords("(1000,500,0)a(99999999999999999999,1,0)b", 0); err == nil || badTag != "(99999999999999999999,1,0)" {
+		t.Errorf("overflowing word tag = %q, %v, want it reported", badTag, err)
+	}
+}
+
+func TestParseKaraokeInvalidTimes(t *testing.T) {
+	//Numbers too large for a time are reported instead of becoming 0, the broken lines are skipped.
+	//过大而无法作为时间的数字会被报告而不是变为0，有问题的行会被跳过。
+	content := "[99999999999999999999,1000](0,1000,0)broken line\n" +
+		"[1000,1000](1000,99999999999,0)broken word\n" +
+		"[2000,1000](2000,1000,0)fine\n"
+	p := &lyricParser{file: "test.yrc"}
+	lyric, err := parseYRC(p, []byte(content), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lyric.Lines) != 1 || lyric.Lines[0].Text != "fine" {
+		t.Errorf("lines = %+v, want only the valid line", lyric.Lines)
+	}
+	if len(p.diagnostics) != 2 || p.diagnostics[0].Severity != SeverityError || p.diagnostics[1].Column != 12 {
+		t.Errorf("diagnostics = %+v, want errors for both broken lines", p.diagnostics)
+	}
+	if _, err := parseYRC(&lyricParser{file: "test.yrc", options: ParseOptions{Strict: true}}, []byte(content), 0); err == nil {
+		t.Error("strict parsing accepted an overflowing line time")
+	}
+}
+
+func TestParseYRC(t *testing.T) {
+	content := `{"t":0,"c":[{"tx":"作词: "},{"tx":"someone"}]}` + "\n[1000,2000](1000,500,0)Hel(1500,1500,0)lo\n"
+	lyric, err := parseYRC(&lyricParser{file: "test.yrc"}, []byte(content), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lyric.Lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lyric.Lines))
+	}
+	if credit := lyric.Lines[0]; credit.Text != "作词: someone" || credit.Words != nil {
+		t.Errorf("credit line = %+v", credit)
+	}
+	if line := lyric.Lines[1]; line.Text != "Hello" || line.TimeUs != 1_000_000 || line.EndUs != 3_000_000 {
+		t.Errorf("line = %+v", line)
+	}
+}
+
+func TestParseQRC(t *testing.T) {
+	document := `<?xml version="1.0" encoding="utf-8"?><QrcInfos><LyricInfo LyricCount="1">` +
+		`<Lyric_1 LyricType="1" LyricContent="[ti:Song]&#10;[1000,2000]Hel(1000,500)lo(1500,1500)&#10;"/></LyricInfo></QrcInfos>`
+	lyric, err := parseQRC(&lyricParser{file: "test.qrc"}, []byte(document), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lyric.Metadata.Title != "Song" || len(lyric.Lines) != 1 || lyric.Lines[0].Text != "Hello" {
+		t.Errorf("lyric = %+v", lyric)
+	}
+
+	_, err = parseQRC(&lyricParser{file: "test.qrc"}, []byte("98A1B2C3D4E5F6"), 0)
+	if err == nil || !strings.Contains(err.Error(), "looks encrypted") {
+		t.Errorf("got error %v for an encrypted file", err)
+	}
+}
+
+func TestParseKRC(t *testing.T) {
+	language := base64.StdEncoding.EncodeToString([]byte(`{"content":[{"type":1,"lyricContent":[["你好"],["世界"]]},{"type":0,"lyricContent":[["ha","ro"]]}]}`))
+	data := encryptKRC(t, "[language:"+language+"]\n[1000,2000]<0,500,0>Hel<500,1500,0>lo\n[4000,1000]<0,1000,0>World\n")
+	lyric, err := parseKRC(&lyricParser{file: "test.krc"}, data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ text, translation, romanization string }{{"Hello", "你好", "ha ro"}, {"World", "世界", ""}}
+	if len(lyric.Lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lyric.Lines), len(want))
+	}
+	for i, line := range lyric.Lines {
+		if line.Text != want[i].text || line.Translation != want[i].translation || line.Romanization != want[i].romanization {
+			t.Errorf("line %d = %q / %q / %q, want %q / %q / %q", i, line.Text, line.Translation, line.Romanization, want[i].text, want[i].translation, want[i].romanization)
+		}
+	}
+
+	_, err = parseKRC(&lyricParser{file: "test.krc"}, []byte("krc1not compressed"), 0)
+	if err == nil || !strings.Contains(err.Error(), "invalid KRC container") {
+		t.Errorf("got error %v for a broken container", err)
+	}
+}
+
+// encryptKRC Compress the lyrics and obfuscate them the way Kugou Music stores .krc files.
+// 按酷狗音乐存储.krc文件的方式压缩并混淆歌词。
+func encryptKRC(t *testing.T, text string) []byte {
+	t.Helper()
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(text)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := compressed.Bytes()
+	for i := range data {
+		data[i] ^= krcKey[i%len(krcKey)]
+	}
+	return append([]byte("krc1"), data...)
+}
+
+func TestParseKaraokeOffset(t *testing.T) {
+	//A negative offset delays the lyrics, the explicit end of the line moves with it.
+	//负的偏移量会推迟歌词，行的明确结束时间随之移动。
+	lyric, err := parseYRC(&lyricParser{file: "test.yrc"}, []byte("[offset:-2000]\n[1000,1000](1000,1000,0)Hello\n"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := lyric.Lines[0]
+	if line.TimeUs != 3_000_000 || line.EndUs != 4_000_000 {
+		t.Errorf("line times = %d-%d, want 3000000-4000000", line.TimeUs, line.EndUs)
+	}
+	if want := []LyricWord{{TimeUs: 3_000_000, EndUs: 4_000_000, Text: "Hello"}}; !slices.Equal(line.Words, want) {
+		t.Errorf("words = %v, want %v", line.Words, want)
+	}
+	if current, _, _ := lyric.LineAt(3_500_000); current == nil || current.Text != "Hello" {
+		t.Errorf("LineAt(3.5s) = %v, want the delayed line", current)
+	}
+}
@@ -0,0 +1,91 @@
+package lyrics
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LintReport The result of checking one audio file and its lyric file.
+// 检查一个音频文件及其歌词文件的结果。
+type LintReport struct {
+	Audio       string       `json:"audio"`
+	Lyric       string       `json:"lyric,omitempty"`
+	Duration    uint64       `json:"durationUs,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// LintLibrary Walk the music directory, pair every audio file with its lyric file and report the problems found.
+// Directories and files that cannot be read get a report with the error, the walk goes on without them.
+// 遍历音乐目录，将每个音频文件与其歌词文件配对并报告发现的问题。无法读取的目录和文件会得到包含该错误的报告，遍历会跳过它们继续进行。
+func LintLibrary(root string, withLog bool) ([]LintReport, error) {
+	var unreadable []LintReport
+	paths, err := FindAudioFiles(root, func(path string, err error) {
+		unreadable = append(unreadable, LintReport{Audio: path, Diagnostics: []Diagnostic{{File: path, Severity: SeverityError, Message: "cannot be read: " + err.Error()}}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]LintReport, 0, len(paths)+len(unreadable))
+	for _, path := range paths {
+		if withLog {
+			log.Printf("[DEBUG] Checking audio file: %s\n", path)
+		}
+		reports = append(reports, LintAudioFile(path))
+	}
+	return append(reports, unreadable...), nil
+}
+
+// FindAudioFiles Walk the music directory and return the paths of the audio files in it. Directories and files that
+// cannot be read are passed to skip, if it is not nil, and left out. An error is only returned if root cannot be read.
+// 遍历音乐目录并返回其中音频文件的路径。无法读取的目录和文件会传给skip（不为nil时）并被忽略。仅当root无法读取时才返回错误。
+func FindAudioFiles(root string, skip func(path string, err error)) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			if skip != nil {
+				skip(path, err)
+			}
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && isAudioFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// LintAudioFile Check the lyric file of one audio file.
+// 检查一个音频文件的歌词文件。
+func LintAudioFile(audioPath string) LintReport {
+	report := LintReport{Audio: audioPath, Diagnostics: []Diagnostic{}}
+	lrcPath := findLyricFile(audioPath)
+	if lrcPath == "" {
+		report.Diagnostics = append(report.Diagnostics, Diagnostic{File: audioPath, Severity: SeverityWarning, Message: fmt.Sprintf("missing lyric file %s with one of the extensions %s", lyricPathFor(audioPath, ""), strings.Join(LyricExtensions, ", "))})
+		return report
+	}
+	report.Lyric = lrcPath
+	dur, err := SongDuration(audioPath)
+	if err != nil {
+		report.Diagnostics = append(report.Diagnostics, Diagnostic{File: audioPath, Severity: SeverityWarning, Message: "failed to get song duration, timestamps are not checked against it: " + err.Error()})
+	}
+	report.Duration = dur
+	_, diagnostics, err := NewLyricWithDiagnostics(lrcPath, dur, ParseOptions{})
+	report.Diagnostics = append(report.Diagnostics, diagnostics...)
+	//A parser that fails has usually reported the error already, it is only added if it was not.
+	//解析失败时通常已报告该错误，仅在未报告时才添加。
+	if err != nil && !slices.ContainsFunc(diagnostics, func(d Diagnostic) bool { return d.Severity == SeverityError }) {
+		report.Diagnostics = append(report.Diagnostics, Diagnostic{File: lrcPath, Severity: SeverityError, Message: err.Error()})
+	}
+	return report
+}
@@ -0,0 +1,59 @@
+package lyrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintAudioFileReportsParseErrorOnce(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "a.mp3")
+	if err := os.WriteFile(audio, []byte("not audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.qrc"), []byte("<QrcInfos"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report := LintAudioFile(audio)
+	var errs []string
+	for _, d := range report.Diagnostics {
+		if d.Severity == SeverityError {
+			errs = append(errs, d.Error())
+		}
+	}
+	if len(errs) != 1 || strings.Count(errs[0], "a.qrc") != 1 {
+		t.Errorf("errors = %q, want the invalid QRC document once", errs)
+	}
+}
+
+func TestLintLibrarySkipsUnreadableDirectories(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("directory permissions do not apply to root")
+	}
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+	for _, dir := range []string{locked, filepath.Join(root, "open")} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "open", "a.mp3"), []byte("not audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+	reports, err := LintLibrary(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || reports[0].Audio != filepath.Join(root, "open", "a.mp3") || reports[1].Audio != locked {
+		t.Fatalf("reports = %+v, want the audio file and the locked directory", reports)
+	}
+	if d := reports[1].Diagnostics; len(d) != 1 || d[0].Severity != SeverityError {
+		t.Errorf("diagnostics of the locked directory = %+v", d)
+	}
+}
@@ -2,35 +2,80 @@ package lyrics
 
 import (
 	"bufio"
-	_ "fmt"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
+)
+
+var (
+	timeTagRegex = regexp.MustCompile(`\[(\d+:[\d:.]+)]`)
+	wordTagRegex = regexp.MustCompile(`<(\d+:[\d:.]+)>`)
+	idTagRegex   = regexp.MustCompile(`^\s*\[([A-Za-z#]+):(.*)]\s*$`)
 )
 
 // Lyric
 // 歌词对象
 type Lyric struct {
 	Lines    []LyricLine
-	Duration uint64 //The total duration of the song, with subtle units. 歌曲总时长，单位微妙。
+	Duration uint64   //The total duration of the song, with subtle units. 歌曲总时长，单位微妙。
+	Metadata Metadata //The ID tags in the header of the lyric file. 歌词文件头部的ID标签。
+	Unsynced bool     //The lyrics have no timing, the times of the lines are spread over the song. 歌词没有时间信息，各行的时间均匀分布在歌曲中。
 	lastIdx  int
 }
 
+// Metadata
+// 歌词元数据
+type Metadata struct {
+	Artist string //[ar:] Artist of the song. 歌手。
+	Title  string //[ti:] Title of the song. 歌曲名。
+	Album  string //[al:] Album of the song. 专辑。
+	Author string //[by:] Creator of the lyric file. 歌词文件的作者。
+	Length uint64 //[length:] Length of the song in microseconds, 0 if absent. 歌曲长度，单位微妙，没有则为0。
+	Offset int64  //[offset:] Offset in milliseconds, a positive value makes the lyrics appear earlier. 偏移量，单位毫秒，正数使歌词提前显示。
+}
+
 // LyricLine
 // 歌词行对象
 type LyricLine struct {
-	TimeUs uint64 //Microsecond, a 64-bit unsigned integer
-	Text   string //Lyrics text
+	TimeUs       uint64      //Microsecond, a 64-bit unsigned integer
+	EndUs        uint64      //The explicit end of the line, 0 means it lasts until the next line. 行的明确结束时间，0表示持续到下一行。
+	Text         string      //Lyrics text
+	Words        []LyricWord //Word-level timing of the enhanced LRC format, empty if the line has none. 增强型LRC的逐字时间，没有则为空。
+	Translation  string      //Translation of the text, empty if the file has none. 歌词的翻译，没有则为空。
+	Romanization string      //Romanization of the text, such as romaji or pinyin. 歌词的罗马音，例如罗马字或拼音。
+	Agent        string      //The singer of the line, empty if the file does not say. 演唱该行的歌手，文件未注明则为空。
+	Background   []LyricWord //Background vocals sung along with the line. 与该行一同演唱的和声。
+}
+
+// LyricWord
+// 歌词字对象
+type LyricWord struct {
+	TimeUs uint64 //The time the word starts, in microseconds. 开始演唱该字的时间，单位微妙。
+	EndUs  uint64 //The time the word ends, 0 means it lasts until the next word. 结束时间，0表示持续到下一个字。
+	Text   string //Word text, the texts of all words joined together form LyricLine.Text. 字文本，所有字拼接后即为行文本。
 }
 
 // NewLyric Create the lyrics file object based on the file path and the duration of the audio file.
-// 通过文件路径和音频文件时长来创建歌词文件对象。
+// Broken lines are skipped, NewLyricWithDiagnostics reports them or stops at them.
+// 通过文件路径和音频文件时长来创建歌词文件对象。有问题的行会被跳过，NewLyricWithDiagnostics会报告它们或在遇到时停止。
 func NewLyric(path string, duration uint64) (*Lyric, error) {
+	lyric, _, err := NewLyricWithDiagnostics(path, duration, ParseOptions{})
+	return lyric, err
+}
+
+// NewLyricWithDiagnostics Create the lyrics file object and return the problems found in the file.
+// Without ParseOptions.Strict, broken lines are skipped and the best-effort result is returned together with the diagnostics.
+// 创建歌词文件对象并返回文件中发现的问题。未启用ParseOptions.Strict时，有问题的行会被跳过，并同时返回尽力解析的结果和诊断信息。
+func NewLyricWithDiagnostics(path string, duration uint64, options ParseOptions) (*Lyric, []Diagnostic, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer func(file *os.File) {
 		err := file.Close()
@@ -38,54 +83,572 @@ func NewLyric(path string, duration uint64) (*Lyric, error) {
 
 		}
 	}(file)
+	if _, ok := lyricFormats[strings.ToLower(filepath.Ext(path))]; ok && options.Format == "" {
+		options.Format = filepath.Ext(path)
+	}
+	return ParseLyric(file, path, duration, options)
+}
+
+// NewLyricFromReader Create the lyrics object from a reader, such as stdin, an HTTP body or embedded tags.
+// Broken lines are skipped, ParseLyric reports them or stops at them.
+// 从读取器创建歌词对象，例如标准输入、HTTP响应体或内嵌标签。有问题的行会被跳过，ParseLyric会报告它们或在遇到时停止。
+func NewLyricFromReader(r io.Reader, duration uint64) (*Lyric, error) {
+	lyric, _, err := ParseLyric(r, "", duration, ParseOptions{})
+	return lyric, err
+}
+
+// NewLyricFromString Create the lyrics object from the content of a lyric file.
+// 从歌词文件的内容创建歌词对象。
+func NewLyricFromString(content string, duration uint64) (*Lyric, error) {
+	return NewLyricFromReader(strings.NewReader(content), duration)
+}
+
+// ParseLyric Parse lyrics from a reader and return the problems found, name is used as the file of the diagnostics.
+// The format is chosen by ParseOptions.Format and defaults to LRC.
+// 从读取器解析歌词并返回发现的问题，name用作诊断信息中的文件名。格式由ParseOptions.Format决定，默认为LRC。
+func ParseLyric(r io.Reader, name string, duration uint64, options ParseOptions) (*Lyric, []Diagnostic, error) {
+	p := &lyricParser{file: name, options: options}
+	format, err := formatOf(options.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	lyric, err := format(p, data, duration)
+	sort.SliceStable(p.diagnostics, func(i, j int) bool {
+		return p.diagnostics[i].Line < p.diagnostics[j].Line
+	})
+	return lyric, p.diagnostics, err
+}
 
+// parseLRC Parse lyrics in the LRC format.
+// 解析LRC格式的歌词。
+func parseLRC(p *lyricParser, data []byte, duration uint64) (*Lyric, error) {
+	text, err := p.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if !timeTagRegex.MatchString(text) {
+		lyric := newUnsyncedLyric(text, duration)
+		if len(lyric.Lines) > 0 {
+			if err := p.report(0, 0, SeverityInfo, "the file has no time tags, its lines are treated as unsynchronized lyrics"); err != nil {
+				return nil, err
+			}
+		}
+		return lyric, nil
+	}
 	var lines []LyricLine
-	scanner := bufio.NewScanner(file)
-	timeTagRegex := regexp.MustCompile(`\[(\d+):(\d+\.\d+)]`)
+	var metadata Metadata
+	//The position of each time tag, used to report timestamps past the duration once the offset is known.
+	//每个时间标签的位置，用于在得知偏移量后报告超出时长的时间戳。
+	type tagPos struct {
+		line, column int
+		tag          string
+		us           uint64
+	}
+	var positions []tagPos
+	//The index of the first line at each timestamp, later lines at the same timestamp are its translation and romanization.
+	//每个时间戳第一行的索引，同一时间戳的后续行为其翻译和罗马音。
+	byTime := make(map[uint64]int)
+	//Whether a line was paired with a translation at the same timestamp, the two-space form is not used then.
+	//是否有行与同一时间戳的翻译配对，此时不使用两个空格的形式。
+	pairedTranslation := false
+	//The first time tag of the previous line, the other tags of a compressed line such as [00:10.00][01:20.00] repeat it
+	//later in the song and do not set the order.
+	//上一行的第一个时间标签，[00:10.00][01:20.00]等压缩行的其他标签表示在歌曲后面重复，不决定顺序。
+	var previousUs uint64
+	ambiguityReported := false
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	lineNo := 0
 	for scanner.Scan() {
+		lineNo++
 		line := scanner.Text()
-		tags := timeTagRegex.FindAllStringSubmatch(line, -1)
+		if strings.TrimSpace(line) == "" {
+			if err := p.report(lineNo, 1, SeverityInfo, "empty line"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !utf8.ValidString(line) {
+			if err := p.report(lineNo, 1, SeverityWarning, "line is not valid UTF-8"); err != nil {
+				return nil, err
+			}
+		}
+		tags := timeTagRegex.FindAllStringSubmatchIndex(line, -1)
+		if len(tags) == 0 {
+			if idTag := idTagRegex.FindStringSubmatch(line); idTag != nil {
+				metadata.set(idTag[1], strings.TrimSpace(idTag[2]))
+				continue
+			}
+			if err := p.report(lineNo, 1, SeverityWarning, "line has no time tag and is ignored: %q", line); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		text := timeTagRegex.ReplaceAllString(line, "")
-		for _, tag := range tags {
-			parseUint, _ := strconv.ParseUint(tag[1], 10, 64)
-			secFloat, _ := strconv.ParseFloat(tag[2], 64)
-			us := parseUint*60*1_000_000 + uint64(secFloat*1_000_000)
-			lines = append(lines, LyricLine{TimeUs: us, Text: strings.TrimSpace(text)})
+		times := make([]uint64, len(tags))
+		broken := false
+		for i, tag := range tags {
+			us, err := parseTimestamp(line[tag[2]:tag[3]])
+			if err != nil {
+				if err := p.report(lineNo, columnOf(line, tag[0]), SeverityError, "invalid time tag %s: %v", line[tag[0]:tag[1]], err); err != nil {
+					return nil, err
+				}
+				broken = true
+				break
+			}
+			if !ambiguityReported && strings.Count(line[tag[2]:tag[3]], ":") == 2 && !strings.Contains(line[tag[2]:tag[3]], ".") {
+				//Reported once, a file uses the same form on every line.
+				//只报告一次，文件的每一行都使用相同的形式。
+				ambiguityReported = true
+				if err := p.report(lineNo, columnOf(line, tag[0]), SeverityWarning, "time tag %s is read as mm:ss:xx (%s), not as hh:mm:ss", line[tag[0]:tag[1]], formatTimestamp(us)); err != nil {
+					return nil, err
+				}
+			}
+			times[i] = us
+			positions = append(positions, tagPos{line: lineNo, column: columnOf(line, tag[0]), tag: line[tag[0]:tag[1]], us: us})
+		}
+		if broken {
+			continue
+		}
+		firstUs := times[0]
+		words, badTag, err := parseWords(text, firstUs)
+		if err != nil {
+			if err := p.report(lineNo, columnOf(line, strings.Index(line, badTag)), SeverityError, "invalid word tag %s: %v", badTag, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if words != nil {
+			text = joinWords(words)
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			if err := p.report(lineNo, 1, SeverityInfo, "lyric line has no text"); err != nil {
+				return nil, err
+			}
+		}
+		if _, shared := byTime[firstUs]; !shared && firstUs < previousUs {
+			if err := p.report(lineNo, columnOf(line, tags[0][0]), SeverityWarning, "time tag %s is earlier than the previous line", line[tags[0][0]:tags[0][1]]); err != nil {
+				return nil, err
+			}
+		}
+		previousUs = firstUs
+		for i, us := range times {
+			if idx, ok := byTime[us]; ok {
+				existing := &lines[idx]
+				if text == "" {
+					continue
+				}
+				if text == existing.Text || text == existing.Translation || text == existing.Romanization {
+					if err := p.report(lineNo, columnOf(line, tags[i][0]), SeverityWarning, "duplicate line at %s", line[tags[i][0]:tags[i][1]]); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if existing.addSubLine(text) {
+					pairedTranslation = true
+				} else {
+					if err := p.report(lineNo, columnOf(line, tags[i][0]), SeverityWarning, "more than three lines share the time tag %s, the line is ignored", line[tags[i][0]:tags[i][1]]); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+			byTime[us] = len(lines)
+			lyricLine := LyricLine{TimeUs: us, Text: text}
+			if words != nil {
+				//Word tags are absolute, a repeated line tag moves them by its distance to the first tag.
+				//逐字标签为绝对时间，重复的行标签需要按与第一个标签的距离平移。
+				lyricLine.Words = shiftWords(words, firstUs, us)
+			}
+			lines = append(lines, lyricLine)
 		}
 	}
-	sort.Slice(lines, func(i, j int) bool {
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !pairedTranslation {
+		splitTranslations(lines)
+	}
+	if metadata.Offset != 0 {
+		applyOffset(lines, metadata.Offset)
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
 		return lines[i].TimeUs < lines[j].TimeUs
 	})
-	return &Lyric{Lines: lines, Duration: duration}, nil
+	if duration == 0 {
+		duration = metadata.Length
+	}
+	if duration > 0 {
+		for _, pos := range positions {
+			if int64(pos.us)-metadata.Offset*1000 > int64(duration) {
+				if err := p.report(pos.line, pos.column, SeverityWarning, "time tag %s is past the end of the song", pos.tag); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return &Lyric{Lines: lines, Duration: duration, Metadata: metadata}, nil
+}
+
+// splitTranslations Split the lines in the "original  translation" form when at least two lines and at least half of
+// the lines of the file have that form, so that a stray double space in ordinary lyrics does not turn the rest of the
+// line into a translation.
+// 当文件中至少两行且至少一半的行是"原文  翻译"形式时拆分这些行，以免普通歌词中偶然出现的两个空格使行的其余部分变为翻译。
+func splitTranslations(lines []LyricLine) {
+	candidates, split := 0, 0
+	for _, line := range lines {
+		if line.Words != nil || line.Text == "" {
+			continue
+		}
+		candidates++
+		if _, translation := splitTranslation(line.Text); translation != "" {
+			split++
+		}
+	}
+	if split < 2 || split*2 < candidates {
+		return
+	}
+	for i := range lines {
+		if lines[i].Words == nil {
+			lines[i].Text, lines[i].Translation = splitTranslation(lines[i].Text)
+		}
+	}
+}
+
+// splitTranslation Split a line in the "original  translation" form, where two spaces separate the original from its translation.
+// 拆分"原文  翻译"形式的行，其中两个空格分隔原文与翻译。
+func splitTranslation(text string) (string, string) {
+	idx := strings.Index(text, "  ")
+	if idx < 0 {
+		return text, ""
+	}
+	original, translation := strings.TrimSpace(text[:idx]), strings.TrimSpace(text[idx:])
+	if original == "" || translation == "" {
+		return text, ""
+	}
+	return original, translation
+}
+
+// addSubLine Attach the text of another line with the same timestamp. The second line is the translation and the third one the romanization,
+// returns false if both are already set.
+// 附加同一时间戳的另一行文本。第二行为翻译，第三行为罗马音，两者都已设置时返回false。
+func (line *LyricLine) addSubLine(text string) bool {
+	switch {
+	case line.Translation == "":
+		line.Translation = text
+	case line.Romanization == "":
+		line.Romanization = text
+	default:
+		return false
+	}
+	return true
+}
+
+// columnOf Convert a byte index in the line into a column starting from 1.
+// 将行内的字节索引转换为从1开始的列号。
+func columnOf(line string, index int) int {
+	if index < 0 {
+		return 1
+	}
+	return utf8.RuneCountInString(line[:index]) + 1
+}
+
+// set Save the value of an ID tag, unknown tags are ignored.
+// 保存ID标签的值，忽略未知的标签。
+func (m *Metadata) set(key, value string) {
+	switch strings.ToLower(key) {
+	case "ar":
+		m.Artist = value
+	case "ti":
+		m.Title = value
+	case "al":
+		m.Album = value
+	case "by":
+		m.Author = value
+	case "length":
+		m.Length = parseLength(value)
+	case "offset":
+		offset, err := strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64)
+		if err == nil {
+			m.Offset = offset
+		}
+	}
+}
+
+// parseLength Parse the value of the [length:] tag, such as 03:25 or 3:25.40, into microseconds.
+// 将[length:]标签的值（例如03:25或3:25.40）解析为微妙。
+func parseLength(value string) uint64 {
+	length, err := parseTimestamp(value)
+	if err != nil {
+		return 0
+	}
+	return length
+}
+
+// applyOffset Move all lines, words and background vocals by the offset of the lyric file, together with their
+// explicit ends. Times earlier than 0 become 0.
+// 按歌词文件的偏移量移动所有的行、字和和声及其明确的结束时间，早于0的时间变为0。
+func applyOffset(lines []LyricLine, offsetMs int64) {
+	shift := func(us uint64) uint64 {
+		shifted := int64(us) - offsetMs*1000
+		if shifted < 0 {
+			return 0
+		}
+		return uint64(shifted)
+	}
+	//An end of 0 means the line or word has no explicit end and stays so.
+	//结束时间为0表示行或字没有明确的结束时间，保持不变。
+	shiftEnd := func(us uint64) uint64 {
+		if us == 0 {
+			return 0
+		}
+		return shift(us)
+	}
+	moveWords := func(words []LyricWord) {
+		for j := range words {
+			words[j].TimeUs = shift(words[j].TimeUs)
+			words[j].EndUs = shiftEnd(words[j].EndUs)
+		}
+	}
+	for i := range lines {
+		lines[i].TimeUs = shift(lines[i].TimeUs)
+		lines[i].EndUs = shiftEnd(lines[i].EndUs)
+		moveWords(lines[i].Words)
+		moveWords(lines[i].Background)
+	}
+}
+
+// parseTimestamp Convert the content of a time tag into microseconds.
+// Supported forms are mm:ss, mm:ss.xx, mm:ss:xx (colon-separated fraction) and hh:mm:ss.xx. Three fields without a
+// dot are read as mm:ss:xx, the form LRC editors write, the LRC parser warns about it as it could be meant as hh:mm:ss.
+// TTML, where hh:mm:ss is the standard form, adds the fraction before calling it.
+// 将时间标签的内容转换为微妙。支持mm:ss、mm:ss.xx、mm:ss:xx（冒号分隔的小数部分）和hh:mm:ss.xx。没有小数点的三个字段按LRC编辑器所写的mm:ss:xx读取，
+// 由于也可能表示hh:mm:ss，LRC解析器会对此发出警告。在hh:mm:ss为标准形式的TTML中，调用前会补上小数部分。
+func parseTimestamp(value string) (uint64, error) {
+	fields := strings.Split(value, ":")
+	var hourStr, minStr, secStr, fracStr string
+	switch len(fields) {
+	case 2:
+		minStr = fields[0]
+		secStr, fracStr, _ = strings.Cut(fields[1], ".")
+	case 3:
+		if strings.Contains(fields[2], ".") {
+			hourStr, minStr = fields[0], fields[1]
+			secStr, fracStr, _ = strings.Cut(fields[2], ".")
+		} else {
+			minStr, secStr, fracStr = fields[0], fields[1], fields[2]
+		}
+	default:
+		return 0, fmt.Errorf("unrecognized timestamp format")
+	}
+	hours, err := parseTimeField(hourStr, "hours", true)
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := parseTimeField(minStr, "minutes", false)
+	if err != nil {
+		return 0, err
+	}
+	if hourStr != "" && minutes >= 60 {
+		return 0, fmt.Errorf("minutes must be less than 60, got %d", minutes)
+	}
+	seconds, err := parseTimeField(secStr, "seconds", false)
+	if err != nil {
+		return 0, err
+	}
+	if seconds >= 60 {
+		return 0, fmt.Errorf("seconds must be less than 60, got %d", seconds)
+	}
+	var fracUs uint64
+	if fracStr != "" || strings.HasSuffix(value, ".") {
+		if fracStr == "" || strings.Trim(fracStr, "0123456789") != "" {
+			return 0, fmt.Errorf("invalid fraction of a second %q", fracStr)
+		}
+		//Pad or cut the fraction to 6 digits, so .5, .50 and .500 all mean 500ms.
+		//将小数部分补齐或截断为6位，使.5、.50和.500都表示500毫秒。
+		fracStr = (fracStr + "000000")[:6]
+		fracUs, _ = strconv.ParseUint(fracStr, 10, 64)
+	}
+	return ((hours*60+minutes)*60+seconds)*1_000_000 + fracUs, nil
+}
+
+// parseTimeField Parse the hours, minutes or seconds field of a timestamp.
+// 解析时间戳的时、分或秒字段。
+func parseTimeField(value, name string, optional bool) (uint64, error) {
+	if value == "" && optional {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return parsed, nil
+}
+
+// parseWords Split the text of an enhanced LRC line into timed words, returns nil if the line has no word tags.
+// A tag without text after it only marks the end of the previous word. On failure the broken tag is returned.
+// 将增强型LRC行文本拆分为带时间的字，没有逐字标签时返回nil。后面没有文本的标签只表示上一个字的结束。失败时返回有问题的标签。
+func parseWords(text string, lineUs uint64) ([]LyricWord, string, error) {
+	locs := wordTagRegex.FindAllStringSubmatchIndex(text, -1)
+	if len(locs) == 0 {
+		return nil, "", nil
+	}
+	var words []LyricWord
+	if lead := strings.TrimLeft(text[:locs[0][0]], " \t"); lead != "" {
+		words = append(words, LyricWord{TimeUs: lineUs, Text: lead})
+	}
+	for i, loc := range locs {
+		us, err := parseTimestamp(text[loc[2]:loc[3]])
+		if err != nil {
+			return nil, text[loc[0]:loc[1]], err
+		}
+		end := len(text)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		wordText := text[loc[1]:end]
+		if len(words) > 0 && words[len(words)-1].EndUs == 0 {
+			if wordText == "" || strings.TrimSpace(wordText) == "" {
+				words[len(words)-1].EndUs = us
+				words[len(words)-1].Text += wordText
+				continue
+			}
+		}
+		if strings.TrimSpace(wordText) == "" {
+			continue
+		}
+		words = append(words, LyricWord{TimeUs: us, Text: wordText})
+	}
+	if len(words) == 0 {
+		return nil, "", nil
+	}
+	words[0].Text = strings.TrimLeft(words[0].Text, " \t")
+	words[len(words)-1].Text = strings.TrimRight(words[len(words)-1].Text, " \t")
+	return words, "", nil
+}
+
+// joinWords Join the texts of words into the line text.
+// 将字文本拼接为行文本。
+func joinWords(words []LyricWord) string {
+	var sb strings.Builder
+	for _, word := range words {
+		sb.WriteString(word.Text)
+	}
+	return sb.String()
+}
+
+// shiftWords Copy the words and move their time from the first line tag to the given line tag.
+// 复制字并将其时间从第一个行标签平移到指定的行标签。
+func shiftWords(words []LyricWord, fromUs, toUs uint64) []LyricWord {
+	shifted := make([]LyricWord, len(words))
+	for i, word := range words {
+		shifted[i] = word
+		shifted[i].TimeUs = shiftTime(word.TimeUs, fromUs, toUs)
+		if word.EndUs != 0 {
+			shifted[i].EndUs = shiftTime(word.EndUs, fromUs, toUs)
+		}
+	}
+	return shifted
+}
+
+func shiftTime(us, fromUs, toUs uint64) uint64 {
+	if toUs >= fromUs {
+		return us + (toUs - fromUs)
+	}
+	if us < fromUs-toUs {
+		return 0
+	}
+	return us - (fromUs - toUs)
 }
 
 // LineAt  Obtain the corresponding line lyrics based on the microseconds currently being played. How much has progress sung for the content of this line?
+// line is nil before the first line and after a line with an explicit end. For lines with word-level timing, progress is the share of characters already sung and word is the index of the active word, otherwise word is -1.
 // 根据当前播放的微妙数获取对应的行歌词。progress为本行内容演唱了多少。
-func (l *Lyric) LineAt(posUs uint64) (text string, progress float64) {
-	if len(l.Lines) == 0 {
-		return "", 0
+// 第一行之前以及有明确结束时间的行结束之后line为nil。对于有逐字时间的行，progress为已演唱字符的占比，word为正在演唱的字的索引，否则word为-1。
+func (l *Lyric) LineAt(posUs uint64) (line *LyricLine, progress float64, word int) {
+	if l == nil || len(l.Lines) == 0 {
+		return nil, 0, -1
 	}
 	if l.lastIdx >= 0 && l.lastIdx < len(l.Lines) {
-		cur := l.Lines[l.lastIdx]
-		nextUs := l.Duration
-		if l.lastIdx+1 < len(l.Lines) {
-			nextUs = l.Lines[l.lastIdx+1].TimeUs
-		}
-		if posUs >= cur.TimeUs && posUs < nextUs {
-			return cur.Text, float64(posUs-cur.TimeUs) / float64(nextUs-cur.TimeUs)
+		cur := &l.Lines[l.lastIdx]
+		endUs := l.endOf(l.lastIdx)
+		if posUs >= cur.TimeUs && posUs < endUs {
+			progress, word = cur.progressAt(posUs, endUs)
+			return cur, progress, word
 		}
 	}
 	idx := sort.Search(len(l.Lines), func(i int) bool {
 		return l.Lines[i].TimeUs > posUs
 	})
 	if idx == 0 {
-		return "", 0
+		return nil, 0, -1
 	}
 	l.lastIdx = idx - 1
-	cur := l.Lines[l.lastIdx]
-	next := LyricLine{TimeUs: l.Duration}
-	if idx < len(l.Lines) {
-		next = l.Lines[idx]
+	cur := &l.Lines[l.lastIdx]
+	endUs := l.endOf(l.lastIdx)
+	if cur.EndUs != 0 && posUs >= endUs {
+		return nil, 0, -1
+	}
+	progress, word = cur.progressAt(posUs, endUs)
+	return cur, progress, word
+}
+
+// endOf Get the time the line ends: its explicit end, otherwise the start of the next line or the end of the song.
+// 获取行的结束时间：优先使用明确的结束时间，否则为下一行的开始时间或歌曲的结束时间。
+func (l *Lyric) endOf(idx int) uint64 {
+	endUs := l.Duration
+	if idx+1 < len(l.Lines) {
+		endUs = l.Lines[idx+1].TimeUs
+	}
+	cur := l.Lines[idx]
+	if cur.EndUs != 0 && (cur.EndUs < endUs || endUs <= cur.TimeUs) {
+		endUs = cur.EndUs
+	}
+	return endUs
+}
+
+// progressAt Calculate how much of the line has been sung, endUs is the time the line ends.
+// 计算本行已演唱的进度，endUs为本行结束的时间。
+func (line *LyricLine) progressAt(posUs, endUs uint64) (float64, int) {
+	if len(line.Words) == 0 {
+		if endUs <= line.TimeUs {
+			return 0, -1
+		}
+		return float64(posUs-line.TimeUs) / float64(endUs-line.TimeUs), -1
+	}
+	total := 0
+	for _, w := range line.Words {
+		total += utf8.RuneCountInString(w.Text)
+	}
+	if total == 0 || posUs < line.Words[0].TimeUs {
+		return 0, -1
+	}
+	sung := 0
+	for i, w := range line.Words {
+		wordEnd := w.EndUs
+		if wordEnd == 0 {
+			wordEnd = endUs
+			if i+1 < len(line.Words) {
+				wordEnd = line.Words[i+1].TimeUs
+			}
+		}
+		runes := utf8.RuneCountInString(w.Text)
+		if posUs < wordEnd {
+			frac := 1.0
+			if wordEnd > w.TimeUs && posUs >= w.TimeUs {
+				frac = float64(posUs-w.TimeUs) / float64(wordEnd-w.TimeUs)
+			} else if posUs < w.TimeUs {
+				//Between two words, the previous word is finished but the next one has not started yet.
+				//处于两个字之间，上一个字已唱完而下一个字尚未开始。
+				return float64(sung) / float64(total), i - 1
+			}
+			return (float64(sung) + frac*float64(runes)) / float64(total), i
+		}
+		sung += runes
 	}
-	return cur.Text, float64(posUs-cur.TimeUs) / float64(next.TimeUs-cur.TimeUs)
+	return 1, len(line.Words) - 1
 }
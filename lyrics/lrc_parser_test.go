@@ -0,0 +1,240 @@
+package lyrics
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseLRCTranslation(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		texts        []string
+		translations []string
+	}{
+		{
+			name:         "two spaces on every line",
+			content:      "[00:01.00]Hello  你好\n[00:02.00]World  世界\n",
+			texts:        []string{"Hello", "World"},
+			translations: []string{"你好", "世界"},
+		},
+		{
+			name:         "stray double space",
+			content:      "[00:01.00]Hello  world\n",
+			texts:        []string{"Hello  world"},
+			translations: []string{""},
+		},
+		{
+			name:         "stray double space among ordinary lines",
+			content:      "[00:01.00]Hello  world\n[00:02.00]Second line\n[00:03.00]Third  line\n[00:04.00]Fourth line\n[00:05.00]Fifth line\n",
+			texts:        []string{"Hello  world", "Second line", "Third  line", "Fourth line", "Fifth line"},
+			translations: []string{"", "", "", "", ""},
+		},
+		{
+			name:         "translation lines with the same time tag",
+			content:      "[00:01.00]Hello  world\n[00:01.00]你好世界\n[00:02.00]Good  night\n[00:02.00]晚安\n",
+			texts:        []string{"Hello  world", "Good  night"},
+			translations: []string{"你好世界", "晚安"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lyric, err := NewLyricFromString(tt.content, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lyric.Lines) != len(tt.texts) {
+				t.Fatalf("got %d lines, want %d", len(lyric.Lines), len(tt.texts))
+			}
+			for i, line := range lyric.Lines {
+				if line.Text != tt.texts[i] || line.Translation != tt.translations[i] {
+					t.Errorf("line %d = %q / %q, want %q / %q", i, line.Text, line.Translation, tt.texts[i], tt.translations[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseLRCOrderWarning(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		warn    bool
+	}{
+		{"in order", "[00:01.00]a\n[00:02.00]b\n", false},
+		{"compressed repeated line", "[00:10.00][01:20.00]chorus\n[00:15.00]verse\n[00:20.00]bridge\n", false},
+		{"earlier than the previous line", "[00:10.00]a\n[00:05.00]b\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diagnostics, err := ParseLyric(strings.NewReader(tt.content), "test.lrc", 0, ParseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			warned := false
+			for _, d := range diagnostics {
+				if strings.Contains(d.Message, "earlier than the previous line") {
+					warned = true
+				}
+			}
+			if warned != tt.warn {
+				t.Errorf("warned = %v, want %v: %v", warned, tt.warn, diagnostics)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		value string
+		us    uint64
+		err   bool
+	}{
+		{"01:02", 62_000_000, false},
+		{"01:02.5", 62_500_000, false},
+		{"01:02.50", 62_500_000, false},
+		{"01:02.500", 62_500_000, false},
+		{"01:02:03", 62_030_000, false},
+		{"01:02:03.5", 3_723_500_000, false},
+		{"01:60", 0, true},
+		{"01:02.", 0, true},
+		{"01:60:00.0", 0, true},
+		{"1:2:3:4", 0, true},
+	}
+	for _, tt := range tests {
+		us, err := parseTimestamp(tt.value)
+		if (err != nil) != tt.err || us != tt.us {
+			t.Errorf("parseTimestamp(%q) = %d, %v", tt.value, us, err)
+		}
+	}
+	if us, err := parseTTMLTime("01:02:03"); err != nil || us != 3_723_000_000 {
+		t.Errorf("parseTTMLTime(01:02:03) = %d, %v, want hh:mm:ss", us, err)
+	}
+}
+
+func TestParseLRCAmbiguousTimestamp(t *testing.T) {
+	lyric, diagnostics, err := ParseLyric(strings.NewReader("[01:02:03]a\n[01:03:00]b\n"), "test.lrc", 0, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lyric.Lines[0].TimeUs != 62_030_000 {
+		t.Errorf("time = %d, want mm:ss:xx", lyric.Lines[0].TimeUs)
+	}
+	warnings := 0
+	for _, d := range diagnostics {
+		if strings.Contains(d.Message, "not as hh:mm:ss") {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("got %d ambiguity warnings, want 1: %v", warnings, diagnostics)
+	}
+}
+
+func TestParseWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []LyricWord
+	}{
+		{"Hello world", nil},
+		{"<00:01.00>Hel<00:01.50>lo <00:02.00>world", []LyricWord{{TimeUs: 1_000_000, Text: "Hel"}, {TimeUs: 1_500_000, Text: "lo "}, {TimeUs: 2_000_000, Text: "world"}}},
+		//A tag after the last word ends it.
+		//最后一个字之后的标签表示其结束。
+		{"<00:01.00>Hel<00:01.50>lo<00:02.25>", []LyricWord{{TimeUs: 1_000_000, Text: "Hel"}, {TimeUs: 1_500_000, EndUs: 2_250_000, Text: "lo"}}},
+		//Text before the first tag starts with the line.
+		//第一个标签之前的文本从行开始时开始。
+		{"Oh <00:01.50>yeah", []LyricWord{{TimeUs: 1_000_000, Text: "Oh "}, {TimeUs: 1_500_000, Text: "yeah"}}},
+	}
+	for _, tt := range tests {
+		words, badTag, err := parseWords(tt.text, 1_000_000)
+		if err != nil || badTag != "" || !slices.Equal(words, tt.want) {
+			t.Errorf("parseWords(%q) = %v, %q, %v, want %v", tt.text, words, badTag, err, tt.want)
+		}
+	}
+	if _, badTag, err := parseWords("<00:01.00>a<1:2:3:4>b", 0); err == nil || badTag != "<1:2:3:4>" {
+		t.Errorf("broken word tag = %q, %v, want it returned", badTag, err)
+	}
+}
+
+func TestLineAt(t *testing.T) {
+	lyric := &Lyric{Lines: []LyricLine{
+		{TimeUs: 1_000_000, Text: "Hello", Words: []LyricWord{{TimeUs: 1_000_000, Text: "Hel"}, {TimeUs: 2_000_000, Text: "lo"}}},
+		{TimeUs: 4_000_000, Text: "Plain"},
+		{TimeUs: 6_000_000, EndUs: 7_000_000, Text: "Ends"},
+	}, Duration: 10_000_000}
+	tests := []struct {
+		posUs    uint64
+		text     string
+		progress float64
+		word     int
+	}{
+		{500_000, "", 0, -1},
+		{1_500_000, "Hello", 0.3, 0},
+		//The last word of a line lasts until the next line.
+		//一行的最后一个字持续到下一行。
+		{3_000_000, "Hello", 0.8, 1},
+		{4_500_000, "Plain", 0.25, -1},
+		{6_500_000, "Ends", 0.5, -1},
+		{8_000_000, "", 0, -1},
+	}
+	for _, tt := range tests {
+		line, progress, word := lyric.LineAt(tt.posUs)
+		text := ""
+		if line != nil {
+			text = line.Text
+		}
+		if text != tt.text || progress != tt.progress || word != tt.word {
+			t.Errorf("LineAt(%d) = %q, %v, %d, want %q, %v, %d", tt.posUs, text, progress, word, tt.text, tt.progress, tt.word)
+		}
+	}
+}
+
+func TestParseLRCMetadata(t *testing.T) {
+	lyric, err := NewLyricFromString("[ar:Artist]\n[TI: Song ]\n[al:Album]\n[by:Someone]\n[length: 03:25.40]\n[00:01.00]Hello\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Metadata{Artist: "Artist", Title: "Song", Album: "Album", Author: "Someone", Length: 205_400_000}
+	if lyric.Metadata != want {
+		t.Errorf("metadata = %+v, want %+v", lyric.Metadata, want)
+	}
+	if len(lyric.Lines) != 1 || lyric.Lines[0].Text != "Hello" {
+		t.Errorf("lines = %+v, want the line after the tags", lyric.Lines)
+	}
+}
+
+func TestParseLRCOffset(t *testing.T) {
+	tests := []struct {
+		offset string
+		want   uint64
+	}{
+		//A positive offset shows the lyrics earlier, a negative one later.
+		//正的偏移量使歌词提前显示，负的偏移量使歌词推迟显示。
+		{"+500", 1_500_000},
+		{"500", 1_500_000},
+		{"-500", 2_500_000},
+		{"3000", 0},
+		{"invalid", 2_000_000},
+	}
+	for _, tt := range tests {
+		lyric, err := NewLyricFromString("[offset:"+tt.offset+"]\n[00:02.00]Hello <00:02.50>world\n", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line := lyric.Lines[0]; line.TimeUs != tt.want || line.Words[0].TimeUs != tt.want {
+			t.Errorf("offset %s: line at %d, first word at %d, want %d", tt.offset, line.TimeUs, line.Words[0].TimeUs, tt.want)
+		}
+	}
+}
+
+func TestNewLyricSkipsBrokenLines(t *testing.T) {
+	content := "[00:01.00]Hello\n[00:99.00]Broken\n[00:03.00]World\n"
+	lyric, err := NewLyricFromString(content, 0)
+	if err != nil || len(lyric.Lines) != 2 {
+		t.Errorf("NewLyricFromString = %+v, %v, want the two valid lines", lyric, err)
+	}
+	if _, _, err := ParseLyric(strings.NewReader(content), "test.lrc", 0, ParseOptions{Strict: true}); err == nil {
+		t.Error("strict parsing accepted a broken time tag")
+	}
+}
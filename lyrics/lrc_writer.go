@@ -0,0 +1,58 @@
+package lyrics
+
+import (
+	"strings"
+)
+
+// LRC Format the lyrics as an LRC file that parses back into the same lines: word timing becomes enhanced LRC word tags,
+// the translation and the romanization become lines sharing the time tag and explicit line ends become empty lines.
+// Unsynchronized lyrics are written as plain text. Singers, background vocals and a romanization without a translation
+// are not kept.
+// 将歌词格式化为可解析回相同行的LRC文件：逐字时间转换为增强型LRC字标签，翻译和罗马音转换为共享时间标签的行，明确的行结束时间转换为空行。
+// 非同步歌词以纯文本写出。歌手、和声以及没有翻译的罗马音不会保留。
+func (l *Lyric) LRC() string {
+	var sb strings.Builder
+	for _, tag := range []struct{ key, value string }{
+		{"ti", l.Metadata.Title}, {"ar", l.Metadata.Artist}, {"al", l.Metadata.Album}, {"by", l.Metadata.Author},
+	} {
+		if tag.value != "" {
+			sb.WriteString("[" + tag.key + ":" + tag.value + "]\n")
+		}
+	}
+	if l.Duration > 0 {
+		sb.WriteString("[length:" + formatTimestamp(l.Duration) + "]\n")
+	}
+	for i, line := range l.Lines {
+		if l.Unsynced {
+			sb.WriteString(line.Text + "\n")
+			continue
+		}
+		timeTag := "[" + formatTimestamp(line.TimeUs) + "]"
+		sb.WriteString(timeTag)
+		if len(line.Words) > 0 {
+			for j, word := range line.Words {
+				sb.WriteString("<" + formatTimestamp(word.TimeUs) + ">" + word.Text)
+				next := uint64(0)
+				if j+1 < len(line.Words) {
+					next = line.Words[j+1].TimeUs
+				}
+				if word.EndUs != 0 && word.EndUs != next {
+					sb.WriteString("<" + formatTimestamp(word.EndUs) + ">")
+				}
+			}
+		} else {
+			sb.WriteString(line.Text)
+		}
+		sb.WriteString("\n")
+		if line.Translation != "" {
+			sb.WriteString(timeTag + line.Translation + "\n")
+			if line.Romanization != "" {
+				sb.WriteString(timeTag + line.Romanization + "\n")
+			}
+		}
+		if line.EndUs != 0 && (i+1 == len(l.Lines) || line.EndUs < l.Lines[i+1].TimeUs) {
+			sb.WriteString("[" + formatTimestamp(line.EndUs) + "]\n")
+		}
+	}
+	return sb.String()
+}
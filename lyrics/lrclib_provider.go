@@ -0,0 +1,176 @@
+package lyrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLRCLIBURL The base URL of the public LRCLIB server.
+// 公共LRCLIB服务器的基础URL。
+const DefaultLRCLIBURL = "https://lrclib.net"
+
+// userAgent LRCLIB asks clients to identify themselves.
+// LRCLIB要求客户端标明身份。
+const userAgent = "nowlyric (https://github.com/Cold-Mint/nowLyric)"
+
+// HTTPProvider Gets lyrics from a server speaking the LRCLIB protocol, /api/get for an exact match and /api/search otherwise.
+// 从使用LRCLIB协议的服务器获取歌词，精确匹配使用/api/get，否则使用/api/search。
+type HTTPProvider struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// lrclibRecord A lyrics record returned by the LRCLIB API.
+// LRCLIB API返回的歌词记录。
+type lrclibRecord struct {
+	ID           int64   `json:"id"`
+	TrackName    string  `json:"trackName"`
+	ArtistName   string  `json:"artistName"`
+	AlbumName    string  `json:"albumName"`
+	Duration     float64 `json:"duration"`
+	Instrumental bool    `json:"instrumental"`
+	PlainLyrics  string  `json:"plainLyrics"`
+	SyncedLyrics string  `json:"syncedLyrics"`
+}
+
+// NewHTTPProvider Create the provider for the LRCLIB-compatible server at baseURL, empty means DefaultLRCLIBURL.
+// 为位于baseURL的LRCLIB兼容服务器创建提供者，为空表示DefaultLRCLIBURL。
+func NewHTTPProvider(baseURL string) HTTPProvider {
+	if baseURL == "" {
+		baseURL = DefaultLRCLIBURL
+	}
+	return HTTPProvider{BaseURL: strings.TrimSuffix(baseURL, "/"), Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (provider HTTPProvider) Name() string {
+	return "http"
+}
+
+func (provider HTTPProvider) Lyric(track Track, duration uint64) (*LyricResult, error) {
+	if track.Title == "" {
+		return nil, ErrNoLyrics
+	}
+	record, source, err := provider.find(track, duration)
+	if err != nil {
+		return nil, err
+	}
+	text := record.SyncedLyrics
+	if text == "" {
+		text = record.PlainLyrics
+	}
+	lyric, diagnostics, err := NewLyricFromText(text, duration, ParseOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &LyricResult{Lyric: lyric, Diagnostics: diagnostics, Source: source}, nil
+}
+
+// find Get the record of the track: /api/get when the artist, album and duration are known, then /api/search.
+// 获取曲目的记录：已知歌手、专辑和时长时使用/api/get，然后使用/api/search。
+func (provider HTTPProvider) find(track Track, duration uint64) (*lrclibRecord, string, error) {
+	if track.Artist() != "" && track.Album != "" && duration > 0 {
+		query := url.Values{}
+		query.Set("track_name", track.Title)
+		query.Set("artist_name", track.Artist())
+		query.Set("album_name", track.Album)
+		query.Set("duration", strconv.FormatUint((duration+500_000)/1_000_000, 10))
+		var record lrclibRecord
+		source, err := provider.get("/api/get", query, &record)
+		if err == nil && hasLyrics(&record) {
+			return &record, source, nil
+		}
+		if err != nil && !errors.Is(err, ErrNoLyrics) {
+			return nil, "", err
+		}
+	}
+	query := url.Values{}
+	query.Set("track_name", track.Title)
+	if track.Artist() != "" {
+		query.Set("artist_name", track.Artist())
+	}
+	var records []lrclibRecord
+	source, err := provider.get("/api/search", query, &records)
+	if err != nil {
+		return nil, "", err
+	}
+	best := bestRecord(records, duration)
+	if best == nil {
+		return nil, "", ErrNoLyrics
+	}
+	return best, fmt.Sprintf("%s (record %d)", source, best.ID), nil
+}
+
+// get Send a GET request to the API and decode the JSON response into v. A 404 response means ErrNoLyrics.
+// 向API发送GET请求并将JSON响应解码到v中。404响应表示ErrNoLyrics。
+func (provider HTTPProvider) get(path string, query url.Values, v any) (string, error) {
+	requestURL := provider.BaseURL + path + "?" + query.Encode()
+	request, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("User-Agent", userAgent)
+	client := provider.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to request %s: %v", path, err)
+	}
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+
+		}
+	}()
+	if response.StatusCode == http.StatusNotFound {
+		return "", ErrNoLyrics
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", path, response.Status)
+	}
+	if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+		return "", fmt.Errorf("invalid response from %s: %v", path, err)
+	}
+	return requestURL, nil
+}
+
+// bestRecord Choose the search result with lyrics closest to the duration, synchronized lyrics are preferred.
+// 选择时长最接近且有歌词的搜索结果，优先选择同步歌词。
+func bestRecord(records []lrclibRecord, duration uint64) *lrclibRecord {
+	var best *lrclibRecord
+	bestCost := math.Inf(1)
+	for i := range records {
+		record := &records[i]
+		if !hasLyrics(record) {
+			continue
+		}
+		cost := 0.0
+		if duration > 0 && record.Duration > 0 {
+			cost = math.Abs(record.Duration - float64(duration)/1_000_000)
+			if cost > 10 {
+				continue
+			}
+		}
+		if record.SyncedLyrics == "" {
+			cost += 100
+		}
+		if cost < bestCost {
+			best, bestCost = record, cost
+		}
+	}
+	return best
+}
+
+// hasLyrics Whether the record has lyrics, records of instrumental tracks have none.
+// 记录是否有歌词，纯音乐曲目的记录没有歌词。
+func hasLyrics(record *lrclibRecord) bool {
+	return !record.Instrumental && (strings.TrimSpace(record.SyncedLyrics) != "" || strings.TrimSpace(record.PlainLyrics) != "")
+}
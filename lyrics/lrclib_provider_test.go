@@ -0,0 +1,104 @@
+package lyrics
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	synced := lrclibRecord{ID: 1, TrackName: "Song", ArtistName: "Artist", AlbumName: "Album", Duration: 200, SyncedLyrics: "[00:01.00]first\n[00:02.00]second\n"}
+	plain := lrclibRecord{ID: 2, TrackName: "Song", ArtistName: "Artist", Duration: 200, PlainLyrics: "first\nsecond\n"}
+	tests := []struct {
+		name     string
+		get      any //The response of /api/get, nil means 404. /api/get的响应，nil表示404。
+		search   any //The response of /api/search, nil means 404. /api/search的响应，nil表示404。
+		track    Track
+		err      error
+		source   string
+		unsynced bool
+		paths    []string
+	}{
+		{
+			name:   "get",
+			get:    synced,
+			track:  Track{Title: "Song", Artists: []string{"Artist"}, Album: "Album"},
+			source: "/api/get?",
+			paths:  []string{"/api/get"},
+		},
+		{
+			name:   "search after get misses",
+			search: []lrclibRecord{{ID: 3, TrackName: "Song", Duration: 300, SyncedLyrics: "[00:01.00]other\n"}, synced},
+			track:  Track{Title: "Song", Artists: []string{"Artist"}, Album: "Album"},
+			source: "(record 1)",
+			paths:  []string{"/api/get", "/api/search"},
+		},
+		{
+			name:  "not found",
+			track: Track{Title: "Song", Artists: []string{"Artist"}, Album: "Album"},
+			err:   ErrNoLyrics,
+			paths: []string{"/api/get", "/api/search"},
+		},
+		{
+			name:   "search without results",
+			search: []lrclibRecord{},
+			track:  Track{Title: "Song"},
+			err:    ErrNoLyrics,
+			paths:  []string{"/api/search"},
+		},
+		{
+			name:     "plain lyrics only",
+			search:   []lrclibRecord{plain},
+			track:    Track{Title: "Song", Artists: []string{"Artist"}},
+			source:   "(record 2)",
+			unsynced: true,
+			paths:    []string{"/api/search"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if r.Header.Get("User-Agent") != userAgent {
+					t.Errorf("User-Agent = %q", r.Header.Get("User-Agent"))
+				}
+				response := tt.get
+				if r.URL.Path == "/api/search" {
+					response = tt.search
+				}
+				if response == nil {
+					http.NotFound(w, r)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(response)
+			}))
+			defer server.Close()
+			result, err := NewHTTPProvider(server.URL).Lyric(tt.track, 200_000_000)
+			if strings.Join(paths, " ") != strings.Join(tt.paths, " ") {
+				t.Errorf("requested %v, want %v", paths, tt.paths)
+			}
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(result.Source, tt.source) {
+				t.Errorf("source = %q, want it to contain %q", result.Source, tt.source)
+			}
+			if result.Lyric.Unsynced != tt.unsynced {
+				t.Errorf("unsynced = %v, want %v", result.Lyric.Unsynced, tt.unsynced)
+			}
+			if len(result.Lyric.Lines) != 2 || result.Lyric.Lines[0].Text != "first" {
+				t.Errorf("lines = %+v", result.Lyric.Lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package lyrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL and DefaultCacheMissTTL How long found lyrics and "not found" results are kept by default.
+// 默认情况下找到的歌词和"未找到"结果的保留时长。
+const (
+	DefaultCacheTTL     = 30 * 24 * time.Hour
+	DefaultCacheMissTTL = 24 * time.Hour
+)
+
+// LyricCache Lyrics fetched by slow providers stored on disk, one JSON file per entry.
+// 慢速提供者获取的歌词在磁盘上的存储，每个条目一个JSON文件。
+type LyricCache struct {
+	Dir     string
+	TTL     time.Duration //How long found lyrics are kept. 找到的歌词的保留时长。
+	MissTTL time.Duration //How long "not found" results are kept. "未找到"结果的保留时长。
+}
+
+// CacheEntry A cached result of a provider for a track.
+// 提供者针对某一曲目的缓存结果。
+type CacheEntry struct {
+	Key      string    `json:"key"`
+	Provider string    `json:"provider"`
+	Path     string    `json:"path,omitempty"`
+	Artist   string    `json:"artist,omitempty"`
+	Title    string    `json:"title,omitempty"`
+	Duration uint64    `json:"duration,omitempty"`
+	Found    bool      `json:"found"`
+	Source   string    `json:"source,omitempty"`
+	Text     string    `json:"text,omitempty"`  //The lyrics in the LRC format, as shown by cache inspect. LRC格式的歌词，供cache inspect显示。
+	Lyric    *Lyric    `json:"lyric,omitempty"` //The lyrics as the provider returned them, with singers and background vocals. 提供者返回的歌词，包含歌手和和声。
+	Created  time.Time `json:"created"`
+	Expires  time.Time `json:"expires"`
+}
+
+// DefaultCacheDir Get the cache directory, $XDG_CACHE_HOME/nowlyric or ~/.cache/nowlyric.
+// 获取缓存目录，$XDG_CACHE_HOME/nowlyric或~/.cache/nowlyric。
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "nowlyric"), nil
+}
+
+// NewLyricCache Create the cache in the default directory with the default TTLs.
+// 使用默认目录和默认保留时长创建缓存。
+func NewLyricCache() (*LyricCache, error) {
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &LyricCache{Dir: dir, TTL: DefaultCacheTTL, MissTTL: DefaultCacheMissTTL}, nil
+}
+
+// Expired Whether the entry is past its expiry time.
+// 条目是否已过期。
+func (entry *CacheEntry) Expired() bool {
+	return time.Now().After(entry.Expires)
+}
+
+// cacheKey Identify a track for a provider: local audio files by their path and modification time, so that edited tags
+// are read again, other tracks by their artist, title and duration in seconds. The http provider includes its server,
+// so that another server is asked again.
+// 为提供者标识一个曲目：本地音频文件按路径和修改时间标识，以便重新读取被编辑的标签；其他曲目按歌手、标题和以秒为单位的时长标识。
+// http提供者还包含其服务器，以便更换服务器后重新请求。
+func cacheKey(provider LyricProvider, track Track, duration uint64) string {
+	name := provider.Name()
+	if httpProvider, ok := provider.(HTTPProvider); ok {
+		name += "\x00" + httpProvider.BaseURL
+	}
+	identity := ""
+	if name == "embedded" && track.Path != "" {
+		modified := ""
+		if info, err := os.Stat(track.Path); err == nil {
+			modified = info.ModTime().UTC().Format(time.RFC3339Nano)
+		}
+		identity = "path\x00" + track.Path + "\x00" + modified
+	} else {
+		identity = fmt.Sprintf("track\x00%s\x00%s\x00%d", normalizeName(track.Artist()), normalizeName(track.Title), (duration+500_000)/1_000_000)
+	}
+	sum := sha256.Sum256([]byte(name + "\x00" + identity))
+	return hex.EncodeToString(sum[:])
+}
+
+// path Get the file of the entry with the key.
+// 获取指定键的条目文件。
+func (cache *LyricCache) path(key string) string {
+	return filepath.Join(cache.Dir, key+".json")
+}
+
+// Get Read the entry with the key, expired entries are returned as well.
+// 读取指定键的条目，过期的条目也会返回。
+func (cache *LyricCache) Get(key string) (*CacheEntry, error) {
+	data, err := os.ReadFile(cache.path(key))
+	if err != nil {
+		return nil, err
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("invalid cache entry %s: %v", key, err)
+	}
+	//The file name is the key that Find and inspect use, a hand-edited key in the file does not matter.
+	//文件名才是Find和inspect使用的键，文件中被手动编辑的键无关紧要。
+	entry.Key = key
+	return &entry, nil
+}
+
+// Put Store the entry, the file is replaced atomically.
+// 存储条目，文件会被原子地替换。
+func (cache *LyricCache) Put(entry *CacheEntry) error {
+	if err := os.MkdirAll(cache.Dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cache.path(entry.Key), data)
+}
+
+// Entries Read all entries, the newest first.
+// 读取所有条目，最新的在前。
+func (cache *LyricCache) Entries() ([]*CacheEntry, error) {
+	files, err := filepath.Glob(filepath.Join(cache.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []*CacheEntry
+	for _, file := range files {
+		entry, err := cache.Get(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Created.After(entries[j].Created)
+	})
+	return entries, nil
+}
+
+// Find Get the entry whose key starts with the prefix.
+// 获取键以prefix开头的条目。
+func (cache *LyricCache) Find(prefix string) (*CacheEntry, error) {
+	files, err := filepath.Glob(filepath.Join(cache.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, file := range files {
+		if key := strings.TrimSuffix(filepath.Base(file), ".json"); strings.HasPrefix(key, prefix) {
+			found = append(found, key)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no cache entry starts with %q", prefix)
+	case 1:
+		return cache.Get(found[0])
+	}
+	return nil, fmt.Errorf("%d cache entries start with %q, use a longer prefix", len(found), prefix)
+}
+
+// Purge Remove the entries, only the expired ones if expiredOnly is set. Returns the number of removed entries.
+// 删除条目，设置expiredOnly时只删除过期的条目。返回删除的条目数量。
+func (cache *LyricCache) Purge(expiredOnly bool) (int, error) {
+	files, err := filepath.Glob(filepath.Join(cache.Dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, file := range files {
+		if expiredOnly {
+			entry, err := cache.Get(strings.TrimSuffix(filepath.Base(file), ".json"))
+			if err == nil && !entry.Expired() {
+				continue
+			}
+		}
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// CachedProvider Keeps the results of another provider in the cache, including "not found" results.
+// Errors such as a failed request are not cached.
+// 将另一个提供者的结果保存在缓存中，包括"未找到"结果。请求失败等错误不会被缓存。
+type CachedProvider struct {
+	Provider LyricProvider
+	Cache    *LyricCache
+}
+
+func (provider CachedProvider) Name() string {
+	return provider.Provider.Name()
+}
+
+func (provider CachedProvider) Lyric(track Track, duration uint64) (*LyricResult, error) {
+	key := cacheKey(provider.Provider, track, duration)
+	if entry, err := provider.Cache.Get(key); err == nil && !entry.Expired() {
+		if !entry.Found {
+			return nil, ErrNoLyrics
+		}
+		source := "cache " + key[:12] + " of " + entry.Source
+		if entry.Lyric != nil {
+			return &LyricResult{Lyric: entry.Lyric, Source: source}, nil
+		}
+		//Entries written before the lyrics were stored only have the LRC text.
+		//存储歌词之前写入的条目只有LRC文本。
+		lyric, diagnostics, err := NewLyricFromText(entry.Text, duration, ParseOptions{})
+		if err == nil {
+			return &LyricResult{Lyric: lyric, Diagnostics: diagnostics, Source: source}, nil
+		}
+	}
+	result, err := provider.Provider.Lyric(track, duration)
+	if err != nil && !errors.Is(err, ErrNoLyrics) {
+		return result, err
+	}
+	now := time.Now()
+	entry := &CacheEntry{Key: key, Provider: provider.Provider.Name(), Path: track.Path, Artist: track.Artist(), Title: track.Title, Duration: duration, Created: now}
+	if err != nil {
+		entry.Expires = now.Add(provider.Cache.MissTTL)
+	} else {
+		entry.Found = true
+		entry.Source = result.Source
+		entry.Text = result.Lyric.LRC()
+		entry.Lyric = result.Lyric
+		entry.Expires = now.Add(provider.Cache.TTL)
+	}
+	//A cache that cannot be written must not hide the lyrics.
+	//无法写入的缓存不能影响歌词的获取。
+	_ = provider.Cache.Put(entry)
+	return result, err
+}
@@ -0,0 +1,75 @@
+package lyrics
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheEntriesKeyFromFileName(t *testing.T) {
+	cache := &LyricCache{Dir: t.TempDir()}
+	files := map[string]string{
+		"short.json": `{"key":"ab","provider":"http"}`,
+		"empty.json": `{}`,
+		"bad.json":   `{`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(cache.Dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := cache.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Key != "short" && entry.Key != "empty" {
+			t.Errorf("key = %q, want the file name", entry.Key)
+		}
+	}
+	entry, err := cache.Find("sh")
+	if err != nil || entry.Key != "short" || entry.Provider != "http" {
+		t.Errorf("Find = %+v, %v", entry, err)
+	}
+}
+
+func TestCachedProviderKeepsLyrics(t *testing.T) {
+	//Singers, background vocals and a romanization without a translation cannot be written as LRC.
+	//歌手、和声以及没有翻译的罗马音无法以LRC写出。
+	lyric := &Lyric{Lines: []LyricLine{{
+		TimeUs: 1_000_000, EndUs: 3_000_000, Text: "Hello", Romanization: "ha ro", Agent: "v1",
+		Words:      []LyricWord{{TimeUs: 1_000_000, EndUs: 2_000_000, Text: "Hel"}, {TimeUs: 2_000_000, EndUs: 3_000_000, Text: "lo"}},
+		Background: []LyricWord{{TimeUs: 2_000_000, EndUs: 3_000_000, Text: "oh"}},
+	}}, Metadata: Metadata{Title: "Song"}}
+	provider := CachedProvider{Provider: fakeProvider{result: &LyricResult{Lyric: lyric, Source: "fake"}}, Cache: &LyricCache{Dir: t.TempDir(), TTL: time.Hour}}
+	track := Track{Title: "Song"}
+	if _, err := provider.Lyric(track, 0); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := provider.Lyric(track, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(cached.Source, "cache ") {
+		t.Fatalf("source = %q, want a cache hit", cached.Source)
+	}
+	if !reflect.DeepEqual(cached.Lyric.Lines, lyric.Lines) || cached.Lyric.Metadata != lyric.Metadata {
+		t.Errorf("cached lyric = %+v, want %+v", cached.Lyric, lyric)
+	}
+}
+
+func TestCacheKeyIncludesServer(t *testing.T) {
+	track := Track{Title: "Song", Artists: []string{"Artist"}}
+	if cacheKey(NewHTTPProvider("https://a.example"), track, 0) == cacheKey(NewHTTPProvider("https://b.example"), track, 0) {
+		t.Error("two servers share the key of a track")
+	}
+	if cacheKey(NewHTTPProvider("https://a.example/"), track, 0) != cacheKey(NewHTTPProvider("https://a.example"), track, 0) {
+		t.Error("a trailing slash changed the key of a track")
+	}
+}
@@ -8,6 +8,9 @@ import (
 
 type LyricCallback struct {
 	OnlyTranslation   bool
+	OnlyOriginal      bool
+	ShowRomanization  bool
+	ShowAgent         bool
 	WithLog           bool
 	RichText          bool
 	SupportExecute    bool
@@ -32,33 +35,31 @@ func (lc *LyricCallback) Paused(playerBusName string, audioFilePath string, lyri
 	}
 }
 
-func (lc *LyricCallback) UpdateLyric(playerBusName, line string, progress float64, lyric *Lyric) {
+func (lc *LyricCallback) UpdateLyric(playerBusName string, line *LyricLine, progress float64, lyric *Lyric) {
+	parts := lc.displayParts(line)
 	if lc.WithLog {
-		fmt.Printf("LyricCallback{OnlyTranslation:%v, WithLog:%v, RichText:%v, SupportExecute:%v, lastLine:%q, PlayedTextColor:%q, UnplayedTextColor:%q, Offset:%f} progress=%f line=%q\n",
-			lc.OnlyTranslation, lc.WithLog, lc.RichText, lc.SupportExecute,
+		fmt.Printf("LyricCallback{OnlyTranslation:%v, OnlyOriginal:%v, ShowRomanization:%v, ShowAgent:%v, WithLog:%v, RichText:%v, SupportExecute:%v, lastLine:%q, PlayedTextColor:%q, UnplayedTextColor:%q, Offset:%f} progress=%f parts=%q\n",
+			lc.OnlyTranslation, lc.OnlyOriginal, lc.ShowRomanization, lc.ShowAgent, lc.WithLog, lc.RichText, lc.SupportExecute,
 			lc.lastLine, lc.PlayedTextColor, lc.UnplayedTextColor, lc.Offset,
-			progress, line)
+			progress, parts)
 	}
-	str := line
-	if lc.OnlyTranslation {
-		if idx := strings.Index(str, "  "); idx > -1 {
-			str = str[idx+2:]
+	if lc.RichText {
+		for i, str := range parts {
+			runes := []rune(str)
+			total := len(runes)
+			played := min(int(float64(total)*(progress+lc.Offset)), total)
+			playedStr := string(runes[:played])
+			unplayedStr := string(runes[played:])
+			parts[i] = fmt.Sprintf(
+				`<span foreground='%s'>%s</span>`+
+					`<span foreground='%s'>%s</span>`,
+				lc.PlayedTextColor, playedStr,
+				lc.UnplayedTextColor, unplayedStr)
 		}
 	}
-	var out string
-	if lc.RichText {
-		runes := []rune(str)
-		total := len(runes)
-		played := min(int(float64(total)*(progress+lc.Offset)), total)
-		playedStr := string(runes[:played])
-		unplayedStr := string(runes[played:])
-		out = fmt.Sprintf(
-			`<span foreground='%s'>%s</span>`+
-				`<span foreground='%s'>%s</span>`,
-			lc.PlayedTextColor, playedStr,
-			lc.UnplayedTextColor, unplayedStr)
-	} else {
-		out = str
+	out := strings.Join(parts, "  ")
+	if lc.ShowAgent && line != nil && line.Agent != "" {
+		out = line.Agent + ": " + out
 	}
 	if lc.SupportExecute {
 		out = "<executor.markup.true> " + out
@@ -72,3 +73,50 @@ func (lc *LyricCallback) UpdateLyric(playerBusName, line string, progress float6
 		WriteCString(lc.Ptr, out, Size)
 	}
 }
+
+// ShowUnsyncedLyric Output all lines of the lyrics at once, one per line.
+// 一次输出歌词的所有行，每行一句。
+func (lc *LyricCallback) ShowUnsyncedLyric(playerBusName string, lyric *Lyric) {
+	texts := make([]string, len(lyric.Lines))
+	for i := range lyric.Lines {
+		texts[i] = strings.Join(lc.displayParts(&lyric.Lines[i]), "  ")
+	}
+	out := strings.Join(texts, "\n")
+	if lc.lastLine == out {
+		return
+	}
+	lc.lastLine = out
+	println(out)
+	if lc.MmapOK {
+		WriteCString(lc.Ptr, out, Size)
+	}
+}
+
+// displayParts Choose which texts of the line are displayed: the original, the background vocals, the romanization and the translation.
+// 选择要显示的行文本：原文、和声、罗马音和翻译。
+func (lc *LyricCallback) displayParts(line *LyricLine) []string {
+	if line == nil {
+		return []string{""}
+	}
+	if lc.OnlyTranslation {
+		if line.Translation != "" {
+			return []string{line.Translation}
+		}
+		return []string{line.Text}
+	}
+	parts := []string{line.Text}
+	if len(line.Background) > 0 {
+		background := joinWords(line.Background)
+		if !strings.HasPrefix(background, "(") {
+			background = "(" + background + ")"
+		}
+		parts = append(parts, background)
+	}
+	if lc.ShowRomanization && line.Romanization != "" {
+		parts = append(parts, line.Romanization)
+	}
+	if !lc.OnlyOriginal && line.Translation != "" {
+		parts = append(parts, line.Translation)
+	}
+	return parts
+}
@@ -0,0 +1,217 @@
+package lyrics
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Overwrite policies of FetchLibrary, what happens to audio files that already have a lyric file.
+// FetchLibrary的覆盖策略，即已有歌词文件的音频文件如何处理。
+const (
+	OverwriteNever    = "never"    //Keep every existing lyric file. 保留所有已有的歌词文件。
+	OverwriteUnsynced = "unsynced" //Replace .lrc and .txt files without timing by synchronized lyrics. 用同步歌词替换没有时间信息的.lrc和.txt文件。
+	OverwriteAlways   = "always"   //Replace or shadow every lyric file the providers find lyrics for. 替换或遮盖提供者能找到歌词的所有歌词文件。
+)
+
+// FetchStatus What FetchLibrary did for an audio file.
+// FetchLibrary对一个音频文件所做的处理。
+type FetchStatus int
+
+const (
+	FetchWritten FetchStatus = iota
+	FetchSkipped
+	FetchNotFound
+	FetchFailed
+)
+
+func (s FetchStatus) String() string {
+	switch s {
+	case FetchWritten:
+		return "written"
+	case FetchSkipped:
+		return "skipped"
+	case FetchNotFound:
+		return "not found"
+	}
+	return "failed"
+}
+
+// FetchOptions The settings of FetchLibrary.
+// FetchLibrary的设置。
+type FetchOptions struct {
+	Providers   []LyricProvider //The providers asked in order, the first lyrics found are written. 按顺序询问的提供者，写入最先找到的歌词。
+	Overwrite   string          //OverwriteNever (default), OverwriteUnsynced or OverwriteAlways.
+	DryRun      bool            //Report what would be written without writing anything. 只报告将要写入的内容，不写入任何文件。
+	Concurrency int             //How many audio files are looked up at the same time, at least 1. 同时查找的音频文件数量，至少为1。
+	WithLog     bool
+}
+
+// FetchResult The outcome of fetching the lyrics of one audio file.
+// 获取一个音频文件歌词的结果。
+type FetchResult struct {
+	Audio    string
+	Lyric    string //The lyric file that was written, or that already exists when skipped. 写入的歌词文件，跳过时为已有的歌词文件。
+	Replaced string //The existing lyric file that is replaced, or shadowed if it is not Lyric, by the new one. 被新歌词文件替换的已有歌词文件，与Lyric不同时为被遮盖的文件。
+	Status   FetchStatus
+	Source   string //Where the written lyrics came from. 写入的歌词的来源。
+	Err      error  //Why the file was skipped or failed. 跳过或失败的原因。
+}
+
+// FetchLibrary Walk the music directory, look up the lyrics of every audio file with the providers and write them as an
+// .lrc file next to it. The results are in the order of the audio files, followed by failed results for the directories
+// and files that cannot be read. Nothing is fetched if root cannot be read.
+// 遍历音乐目录，使用提供者查找每个音频文件的歌词，并将其写入音频文件旁的.lrc文件。结果按音频文件的顺序排列，其后是无法读取的目录和文件的失败结果。
+// root无法读取时不获取任何歌词。
+func FetchLibrary(root string, options FetchOptions) ([]FetchResult, error) {
+	var unreadable []FetchResult
+	paths, err := FindAudioFiles(root, func(path string, err error) {
+		unreadable = append(unreadable, FetchResult{Audio: path, Status: FetchFailed, Err: err})
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]FetchResult, len(paths))
+	concurrency := max(options.Concurrency, 1)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = FetchAudioFile(paths[i], options)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return append(results, unreadable...), nil
+}
+
+// FetchAudioFile Look up the lyrics of one audio file and write them as an .lrc file next to it, following the
+// overwrite policy of the options. Lyrics found in a lyric file of another format, such as TTML, are copied in that
+// format, so that singers, background vocals and syllable timing are kept.
+// 查找一个音频文件的歌词，并按选项中的覆盖策略将其写入音频文件旁的.lrc文件。在其他格式（例如TTML）的歌词文件中找到的歌词会以该格式复制，以保留歌手、和声和逐音节时间。
+func FetchAudioFile(audioPath string, options FetchOptions) FetchResult {
+	result := FetchResult{Audio: audioPath}
+	existing := findLyricFile(audioPath)
+	if existing != "" {
+		switch options.Overwrite {
+		case OverwriteAlways:
+		case OverwriteUnsynced:
+			//Other formats are richer than plain LRC, they are only replaced with OverwriteAlways.
+			//其他格式比普通LRC更丰富，只有OverwriteAlways才会替换它们。
+			if ext := strings.ToLower(filepath.Ext(existing)); ext != ".lrc" && ext != ".txt" {
+				return FetchResult{Audio: audioPath, Lyric: existing, Status: FetchSkipped, Err: errors.New("the lyric file is not an LRC or text file")}
+			}
+			lyric, _, err := NewLyricWithDiagnostics(existing, 0, ParseOptions{})
+			if err == nil && !lyric.Unsynced {
+				return FetchResult{Audio: audioPath, Lyric: existing, Status: FetchSkipped, Err: errors.New("the lyric file is synchronized")}
+			}
+		default:
+			return FetchResult{Audio: audioPath, Lyric: existing, Status: FetchSkipped, Err: errors.New("the lyric file already exists")}
+		}
+		result.Replaced = existing
+	}
+	if options.WithLog {
+		log.Printf("[DEBUG] Fetching lyrics for: %s\n", audioPath)
+	}
+	track, err := TrackFromFile(audioPath)
+	if err != nil && options.WithLog {
+		log.Printf("[WARN] Failed to read the tags of %s: %v\n", audioPath, err)
+	}
+	dur, err := SongDuration(audioPath)
+	if err != nil && options.WithLog {
+		log.Printf("[WARN] Failed to get the duration of %s: %v\n", audioPath, err)
+	}
+	var found *LyricResult
+	var lastErr error
+	for _, provider := range options.Providers {
+		lyricResult, err := provider.Lyric(track, dur)
+		if err == nil {
+			found = lyricResult
+			break
+		}
+		if !errors.Is(err, ErrNoLyrics) {
+			lastErr = err
+			if options.WithLog {
+				log.Printf("[ERROR] The %s provider failed for %s: %v\n", provider.Name(), audioPath, err)
+			}
+		}
+	}
+	switch {
+	case found != nil:
+	case lastErr != nil:
+		result.Status, result.Err = FetchFailed, lastErr
+		return result
+	default:
+		result.Status, result.Err = FetchNotFound, ErrNoLyrics
+		return result
+	}
+	result.Source = found.Source
+	if existing != "" && options.Overwrite == OverwriteUnsynced && found.Lyric.Unsynced {
+		return FetchResult{Audio: audioPath, Lyric: existing, Status: FetchSkipped, Source: found.Source, Err: errors.New("the lyrics found are not synchronized either")}
+	}
+	var data []byte
+	result.Lyric, data, err = fetchedFile(audioPath, found, existing)
+	if err != nil {
+		result.Status, result.Err = FetchFailed, err
+		return result
+	}
+	//The search provider can find the lyric file next to the audio file, writing it again would only lose information.
+	//搜索提供者可能找到音频文件旁的歌词文件，再次写入只会丢失信息。
+	if found.Path != "" && (sameFile(found.Path, existing) || sameFile(found.Path, result.Lyric)) {
+		return FetchResult{Audio: audioPath, Lyric: found.Path, Status: FetchSkipped, Source: found.Source, Err: errors.New("the lyrics found are the lyric file of the audio file")}
+	}
+	if !options.DryRun {
+		if err := writeFileAtomic(result.Lyric, data); err != nil {
+			result.Status, result.Err = FetchFailed, err
+			return result
+		}
+	}
+	result.Status = FetchWritten
+	return result
+}
+
+// fetchedFile Get the lyric file to write next to the audio file and its content. Lyrics read from a lyric file that is
+// not LRC are copied with their extension, unless the existing lyric file would still be found first; everything else
+// is written as an .lrc file.
+// 获取要写入音频文件旁的歌词文件及其内容。从非LRC歌词文件读取的歌词会连同扩展名一起复制，除非已有的歌词文件仍会先被找到；其他情况均写入.lrc文件。
+func fetchedFile(audioPath string, found *LyricResult, existing string) (string, []byte, error) {
+	if ext := strings.ToLower(filepath.Ext(found.Path)); found.Path != "" && ext != ".lrc" {
+		target := lyricPathFor(audioPath, ext)
+		if existing == "" || existing == target || lookupOrder(existing) > lookupOrder(target) {
+			data, err := os.ReadFile(found.Path)
+			return target, data, err
+		}
+	}
+	return lyricPathFor(audioPath, ".lrc"), []byte(found.Lyric.LRC()), nil
+}
+
+// sameFile Whether both paths name the same existing file.
+// 两个路径是否指向同一个已存在的文件。
+func sameFile(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	return err == nil && os.SameFile(infoA, infoB)
+}
+
+// lookupOrder Get the position of the extension of the lyric file in LyricExtensions.
+// 获取歌词文件扩展名在LyricExtensions中的位置。
+func lookupOrder(path string) int {
+	return slices.Index(LyricExtensions, strings.ToLower(filepath.Ext(path)))
+}
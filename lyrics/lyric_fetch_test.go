@@ -0,0 +1,166 @@
+package lyrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeProvider Returns the same result for every track.
+// 对每个曲目都返回相同的结果。
+type fakeProvider struct {
+	result *LyricResult
+}
+
+func (provider fakeProvider) Name() string {
+	return "fake"
+}
+
+func (provider fakeProvider) Lyric(track Track, duration uint64) (*LyricResult, error) {
+	if provider.result == nil {
+		return nil, ErrNoLyrics
+	}
+	return provider.result, nil
+}
+
+func TestFetchAudioFile(t *testing.T) {
+	synced, err := NewLyricFromString("[00:01.00]synced\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ttmlSource := filepath.Join(t.TempDir(), "Song.ttml")
+	ttml := `<tt xmlns="http://www.w3.org/ns/ttml"><body><div><p begin="00:01.000" end="00:02.000" agent="v1">ttml</p></div></body></tt>`
+	if err := os.WriteFile(ttmlSource, []byte(ttml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fromTTML, _, err := NewLyricWithDiagnostics(ttmlSource, 0, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		existing  string //The extension and content of the existing lyric file. 已有歌词文件的扩展名和内容。
+		content   string
+		overwrite string
+		result    *LyricResult
+		status    FetchStatus
+		written   string //The extension of the lyric file written. 写入的歌词文件的扩展名。
+		replaced  string
+	}{
+		{name: "new", result: &LyricResult{Lyric: synced}, status: FetchWritten, written: ".lrc"},
+		{name: "existing kept", existing: ".lrc", content: "[00:01.00]old\n", overwrite: OverwriteNever, result: &LyricResult{Lyric: synced}, status: FetchSkipped},
+		{name: "unsynced lrc replaced", existing: ".lrc", content: "old\n", overwrite: OverwriteUnsynced, result: &LyricResult{Lyric: synced}, status: FetchWritten, written: ".lrc", replaced: ".lrc"},
+		{name: "unsynced txt shadowed", existing: ".txt", content: "old\n", overwrite: OverwriteUnsynced, result: &LyricResult{Lyric: synced}, status: FetchWritten, written: ".lrc", replaced: ".txt"},
+		{name: "ttml kept by unsynced", existing: ".ttml", content: ttml, overwrite: OverwriteUnsynced, result: &LyricResult{Lyric: synced}, status: FetchSkipped},
+		{name: "srt shadowed by always", existing: ".srt", content: "1\n00:00:01,000 --> 00:00:02,000\nold\n", overwrite: OverwriteAlways, result: &LyricResult{Lyric: synced}, status: FetchWritten, written: ".lrc", replaced: ".srt"},
+		{name: "ttml copied", result: &LyricResult{Lyric: fromTTML, Path: ttmlSource}, status: FetchWritten, written: ".ttml"},
+		{name: "ttml converted when an lrc comes first", existing: ".lrc", content: "[00:01.00]old\n", overwrite: OverwriteAlways, result: &LyricResult{Lyric: fromTTML, Path: ttmlSource}, status: FetchWritten, written: ".lrc", replaced: ".lrc"},
+		{name: "not found", status: FetchNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			audio := filepath.Join(dir, "Song.flac")
+			if err := os.WriteFile(audio, nil, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing != "" {
+				if err := os.WriteFile(lyricPathFor(audio, tt.existing), []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			result := FetchAudioFile(audio, FetchOptions{Providers: []LyricProvider{fakeProvider{tt.result}}, Overwrite: tt.overwrite})
+			if result.Status != tt.status {
+				t.Fatalf("status = %v (%v), want %v", result.Status, result.Err, tt.status)
+			}
+			if tt.written == "" {
+				return
+			}
+			if result.Lyric != lyricPathFor(audio, tt.written) {
+				t.Errorf("wrote %s, want %s", result.Lyric, tt.written)
+			}
+			replaced := ""
+			if tt.replaced != "" {
+				replaced = lyricPathFor(audio, tt.replaced)
+			}
+			if result.Replaced != replaced {
+				t.Errorf("replaced %q, want %q", result.Replaced, replaced)
+			}
+			if findLyricFile(audio) != result.Lyric {
+				t.Errorf("the lyric file found is %s, not the written %s", findLyricFile(audio), result.Lyric)
+			}
+			lyric, _, err := NewLyricWithDiagnostics(result.Lyric, 0, ParseOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lyric.Lines[0].Text != tt.result.Lyric.Lines[0].Text {
+				t.Errorf("written text = %q", lyric.Lines[0].Text)
+			}
+			if tt.written == ".ttml" && lyric.Lines[0].Agent != "v1" {
+				t.Errorf("the agent of the TTML file was lost")
+			}
+		})
+	}
+}
+
+func TestFetchLibraryWalkErrors(t *testing.T) {
+	synced, err := NewLyricFromString("[00:01.00]synced\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := FetchOptions{Providers: []LyricProvider{fakeProvider{&LyricResult{Lyric: synced}}}}
+	if results, err := FetchLibrary(filepath.Join(t.TempDir(), "missing"), options); err == nil || results != nil {
+		t.Errorf("FetchLibrary of a missing directory = %v, %v, want only an error", results, err)
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("directory permissions do not apply to root")
+	}
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+	if err := os.Mkdir(locked, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "Song.flac"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0o755)
+	results, err := FetchLibrary(root, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Status != FetchWritten || results[1].Audio != locked || results[1].Status != FetchFailed {
+		t.Errorf("results = %+v, want the written song and the failed directory", results)
+	}
+}
+
+func TestFetchAudioFileFindsItsOwnLyricFile(t *testing.T) {
+	for _, ext := range []string{".lrc", ".ttml"} {
+		dir := t.TempDir()
+		audio := filepath.Join(dir, "Song.flac")
+		sidecar := lyricPathFor(audio, ext)
+		content := "[00:01.00]<00:01.00>word <00:01.50>by word\n"
+		if ext == ".ttml" {
+			content = `<tt xmlns="http://www.w3.org/ns/ttml"><body><div><p begin="00:01.000" end="00:02.000" agent="v1">ttml</p></div></body></tt>`
+		}
+		if err := os.WriteFile(audio, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(sidecar, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		lyric, _, err := NewLyricWithDiagnostics(sidecar, 0, ParseOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := FetchAudioFile(audio, FetchOptions{Providers: []LyricProvider{fakeProvider{&LyricResult{Lyric: lyric, Path: sidecar}}}, Overwrite: OverwriteAlways})
+		if result.Status != FetchSkipped {
+			t.Errorf("%s: status = %v, want skipped", ext, result.Status)
+		}
+		if data, err := os.ReadFile(sidecar); err != nil || string(data) != content {
+			t.Errorf("%s: the lyric file was rewritten: %q, %v", ext, data, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package lyrics
+
+import (
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay How long the watcher waits after the last change before reporting it, editors often write a file in
+// several steps.
+// 最后一次变化后等待多久再报告，编辑器通常分多步写入文件。
+const reloadDelay = 150 * time.Millisecond
+
+// LyricFileWatcher Watches the lyric file being displayed and reports when it is written or replaced.
+// The directory is watched rather than the file, because editors often save by writing a temporary file and renaming
+// it over the original, which replaces the watched file.
+// 监视正在显示的歌词文件，并在其被写入或替换时报告。监视的是目录而不是文件，因为编辑器通常先写入临时文件再将其重命名为原文件，这会替换被监视的文件。
+type LyricFileWatcher struct {
+	watcher  *fsnotify.Watcher
+	mu       sync.Mutex
+	dir      string
+	path     string
+	timer    *time.Timer
+	OnChange func(path string) //Called on its own goroutine after the file changed. 文件变化后在单独的协程中调用。
+}
+
+// NewLyricFileWatcher Create a watcher that calls onChange when the watched file changes, Run has to be called to
+// receive the events.
+// 创建在被监视文件变化时调用onChange的监视器，需要调用Run才能接收事件。
+func NewLyricFileWatcher(onChange func(path string)) (*LyricFileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &LyricFileWatcher{watcher: watcher, OnChange: onChange}, nil
+}
+
+// Watch Start watching the lyric file instead of the previous one, an empty path stops watching.
+// 开始监视指定的歌词文件以代替之前的文件，路径为空时停止监视。
+func (w *LyricFileWatcher) Watch(path string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	dir := ""
+	if path != "" {
+		path = filepath.Clean(path)
+		dir = filepath.Dir(path)
+	}
+	w.path = path
+	if dir == w.dir {
+		return nil
+	}
+	if w.dir != "" {
+		_ = w.watcher.Remove(w.dir)
+	}
+	w.dir = ""
+	if dir == "" {
+		return nil
+	}
+	if err := w.watcher.Add(dir); err != nil {
+		return err
+	}
+	w.dir = dir
+	return nil
+}
+
+// Run Receive the file events until the watcher is closed.
+// 接收文件事件，直到监视器被关闭。
+func (w *LyricFileWatcher) Run(withLog bool) {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			//Removing or renaming the file away is ignored, an atomic save creates it again right after.
+			//删除文件或将其重命名为其他名称会被忽略，原子保存会随后重新创建该文件。
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			w.mu.Lock()
+			if event.Name == w.path {
+				if withLog {
+					log.Printf("[DEBUG] Lyric file changed: %s %s\n", event.Op, event.Name)
+				}
+				if w.timer != nil {
+					w.timer.Stop()
+				}
+				path := w.path
+				w.timer = time.AfterFunc(reloadDelay, func() {
+					w.OnChange(path)
+				})
+			}
+			w.mu.Unlock()
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			if withLog {
+				log.Printf("[WARN] Lyric file watcher error: %v\n", err)
+			}
+		}
+	}
+}
+
+// Close Stop watching, Run returns.
+// 停止监视，Run随之返回。
+func (w *LyricFileWatcher) Close() error {
+	_ = w.Watch("")
+	return w.watcher.Close()
+}
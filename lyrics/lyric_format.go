@@ -0,0 +1,58 @@
+package lyrics
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// lyricFormat Parse the content of a lyric file in one format.
+// 解析一种格式的歌词文件内容。
+type lyricFormat func(p *lyricParser, data []byte, duration uint64) (*Lyric, error)
+
+// LyricExtensions The extensions of the supported lyric files, in the order they are looked up next to the audio file.
+// 支持的歌词文件扩展名，按在音频文件旁查找的顺序排列。
+var LyricExtensions = []string{".lrc", ".ttml", ".yrc", ".qrc", ".krc", ".ass", ".ssa", ".srt", ".vtt", ".txt"}
+
+var lyricFormats = map[string]lyricFormat{
+	".lrc":  parseLRC,
+	".ttml": parseTTML,
+	".yrc":  parseYRC,
+	".qrc":  parseQRC,
+	".krc":  parseKRC,
+	".ass":  parseASS,
+	".ssa":  parseASS,
+	".srt":  parseSRT,
+	".vtt":  parseVTT,
+	".txt":  parseText,
+}
+
+// formatOf Get the parser of the format given by its extension, with or without the leading dot.
+// 根据扩展名（可以不带前导点）获取对应格式的解析器。
+func formatOf(ext string) (lyricFormat, error) {
+	if ext == "" {
+		return parseLRC, nil
+	}
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	format, ok := lyricFormats[ext]
+	if !ok {
+		return nil, fmt.Errorf("unsupported lyric format %q", ext)
+	}
+	return format, nil
+}
+
+// findLyricFile Find the lyric file next to the audio file, for example a.flac matches a.lrc, a.srt or a.vtt.
+// Returns an empty string if there is none.
+// 查找与音频文件同级的歌词文件，例如a.flac对应a.lrc、a.srt或a.vtt。没有则返回空字符串。
+func findLyricFile(audioPath string) string {
+	for _, ext := range LyricExtensions {
+		lyricPath := lyricPathFor(audioPath, ext)
+		if _, err := os.Stat(lyricPath); err == nil {
+			return lyricPath
+		}
+	}
+	return ""
+}
@@ -0,0 +1,237 @@
+package lyrics
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultMatchThreshold The lowest score a fuzzy match needs when LyricSearch.Threshold is 0.
+// A file named after the title alone scores 0.75, a file named after the artist and the title scores 1.
+// LyricSearch.Threshold为0时模糊匹配所需的最低分数。仅以标题命名的文件得分为0.75，以歌手和标题命名的文件得分为1。
+const DefaultMatchThreshold = 0.7
+
+// matchDepth How deep the search directories are walked for fuzzy matching.
+// 模糊匹配时遍历搜索目录的深度。
+const matchDepth = 3
+
+var (
+	featRegex        = regexp.MustCompile(`(?i)\s(feat|ft|featuring)\.?\s.*$`)
+	versionRegex     = regexp.MustCompile(`(?i)\s-\s.*\b(remaster(ed)?|live|version|edit|mix|mono|stereo|acoustic|instrumental)\b.*$`)
+	bracketRegex     = regexp.MustCompile(`[(\[{【「『][^)\]}】」』]*[)\]}】」』]`)
+	trackNumberRegex = regexp.MustCompile(`^\d{1,3}[\s.\-_]+`)
+)
+
+// LyricMatch A lyric file found by fuzzy matching and its score between 0 and 1.
+// 通过模糊匹配找到的歌词文件及其0到1之间的分数。
+type LyricMatch struct {
+	Path  string
+	Score float64
+}
+
+// Match Find the lyric file whose name best matches the artist, the title and the length of the track, in the directory
+// of the audio file and in the search directories. Lyric files next to an audio file with the same name belong to that
+// audio file and are skipped, so that "Song (Live).flac" does not pick up the Song.lrc of another track of the album.
+// best is the best candidate, ok reports whether it reaches the threshold.
+// 在音频文件所在目录和搜索目录中查找名称与曲目的歌手、标题和时长最匹配的歌词文件。与同名音频文件相邻的歌词文件属于该音频文件，会被跳过，
+// 以免"Song (Live).flac"匹配到专辑中另一曲目的Song.lrc。best为最佳候选，ok表示其是否达到阈值。
+func (search LyricSearch) Match(track Track) (best LyricMatch, ok bool) {
+	title := normalizeName(track.Title)
+	if title == "" {
+		return LyricMatch{}, false
+	}
+	threshold := search.Threshold
+	if threshold == 0 {
+		threshold = DefaultMatchThreshold
+	}
+	titleGrams := nameGrams(title)
+	artistGrams := nameGrams(normalizeName(track.Artist()))
+	var matches []LyricMatch
+	audioStems := make(map[string]map[string]bool)
+	score := func(path, rel string) {
+		if otherAudioFile(path, track.Path, audioStems) {
+			return
+		}
+		var parts []string
+		for _, part := range strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))), "/") {
+			parts = append(parts, trackNumberRegex.ReplaceAllString(part, ""))
+		}
+		candidate := nameGrams(normalizeName(strings.Join(parts, " ")))
+		matches = append(matches, LyricMatch{Path: path, Score: nameScore(titleGrams, artistGrams, candidate)})
+	}
+	if track.Path != "" {
+		dir := filepath.Dir(track.Path)
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if !entry.IsDir() && isLyricFile(entry.Name()) {
+				score(filepath.Join(dir, entry.Name()), entry.Name())
+			}
+		}
+	}
+	for _, dir := range search.Dirs {
+		dir = expandHome(dir)
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(dir, path)
+			if d.IsDir() {
+				if path != dir && (strings.HasPrefix(d.Name(), ".") || strings.Count(rel, string(filepath.Separator)) >= matchDepth-1) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if isLyricFile(path) {
+				score(path, rel)
+			}
+			return nil
+		})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	//Only the candidates that can reach the threshold are opened to compare their length with the track.
+	//只打开可能达到阈值的候选文件，以比较其时长与曲目时长。
+	for i := range matches {
+		if matches[i].Score < threshold-0.05 || track.Length == 0 {
+			break
+		}
+		matches[i].Score += lengthScore(matches[i].Path, track.Length)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) == 0 {
+		return LyricMatch{}, false
+	}
+	best = matches[0]
+	best.Score = min(best.Score, 1)
+	return best, best.Score >= threshold
+}
+
+// otherAudioFile Whether the lyric file is the sidecar of an audio file other than audioPath. The stems of the audio
+// files of each directory are read once into stems.
+// 歌词文件是否为audioPath以外的音频文件的同名歌词文件。每个目录中音频文件的主文件名只读取一次并存入stems。
+func otherAudioFile(lyricPath, audioPath string, stems map[string]map[string]bool) bool {
+	dir := filepath.Dir(lyricPath)
+	dirStems, ok := stems[dir]
+	if !ok {
+		dirStems = make(map[string]bool)
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if !entry.IsDir() && isAudioFile(entry.Name()) {
+				dirStems[strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))] = true
+			}
+		}
+		stems[dir] = dirStems
+	}
+	stem := strings.TrimSuffix(lyricPath, filepath.Ext(lyricPath))
+	if audioPath != "" && stem == strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) {
+		return false
+	}
+	return dirStems[filepath.Base(stem)]
+}
+
+// nameScore Score a candidate file name: the title counts for 0.75 and is compared with the name without the artist,
+// the artist counts for 0.25.
+// 为候选文件名打分：标题占0.75，与去掉歌手后的名称比较；歌手占0.25。
+func nameScore(title, artist, candidate map[string]bool) float64 {
+	rest := make(map[string]bool)
+	for gram := range candidate {
+		if !artist[gram] || title[gram] {
+			rest[gram] = true
+		}
+	}
+	titleScore := 0.0
+	if len(title)+len(rest) > 0 {
+		titleScore = 2 * float64(overlap(title, rest)) / float64(len(title)+len(rest))
+	}
+	if len(artist) == 0 {
+		return titleScore
+	}
+	return 0.75*titleScore + 0.25*float64(overlap(artist, candidate))/float64(len(artist))
+}
+
+// lengthScore Compare the length of the lyric file with the length of the track: a matching [length:] tag adds to the score,
+// a different length or lines past the end of the track subtract from it.
+// 比较歌词文件与曲目的时长：匹配的[length:]标签加分，时长不同或有超出曲目结尾的行则减分。
+func lengthScore(path string, lengthUs uint64) float64 {
+	lyric, _, err := NewLyricWithDiagnostics(path, 0, ParseOptions{})
+	if err != nil || lyric == nil {
+		return 0
+	}
+	if lyric.Metadata.Length > 0 {
+		diff := max(lyric.Metadata.Length, lengthUs) - min(lyric.Metadata.Length, lengthUs)
+		switch {
+		case diff <= 3_000_000:
+			return 0.05
+		case diff > 10_000_000:
+			return -0.2
+		}
+		return 0
+	}
+	if n := len(lyric.Lines); n > 0 && lyric.Lines[n-1].TimeUs > lengthUs+5_000_000 {
+		return -0.2
+	}
+	return 0
+}
+
+// normalizeName Normalize a title, an artist or a file name for comparison: full-width characters become half-width,
+// letters become lower case, featured artists and version suffixes such as "(Remastered 2011)" are removed
+// and punctuation becomes spaces.
+// 规范化标题、歌手或文件名以便比较：全角字符转换为半角，字母转换为小写，移除合作歌手以及"(Remastered 2011)"等版本后缀，标点转换为空格。
+func normalizeName(name string) string {
+	name = strings.ToLower(norm.NFKC.String(name))
+	name = bracketRegex.ReplaceAllString(name, " ")
+	name = featRegex.ReplaceAllString(name, "")
+	name = versionRegex.ReplaceAllString(name, "")
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return r
+		}
+		return ' '
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// nameGrams Split each word of a normalized name into pairs of adjacent characters, a word of one character is kept whole.
+// Titles in Chinese or Japanese have no spaces and are compared by their character pairs as well.
+// 将规范化名称中的每个词拆分为相邻字符对，单字符的词保持原样。中文或日文标题没有空格，同样按字符对比较。
+func nameGrams(name string) map[string]bool {
+	grams := make(map[string]bool)
+	for _, word := range strings.Fields(name) {
+		runes := []rune(word)
+		if len(runes) == 1 {
+			grams[word] = true
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			grams[string(runes[i:i+2])] = true
+		}
+	}
+	return grams
+}
+
+// overlap Count the grams a and b have in common.
+// 统计a和b共有的字符对数量。
+func overlap(a, b map[string]bool) int {
+	n := 0
+	for gram := range a {
+		if b[gram] {
+			n++
+		}
+	}
+	return n
+}
+
+// isLyricFile Whether the file has the extension of a supported lyric format.
+// 文件是否具有受支持歌词格式的扩展名。
+func isLyricFile(path string) bool {
+	_, ok := lyricFormats[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
@@ -0,0 +1,42 @@
+package lyrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchSkipsSidecarsOfOtherTracks(t *testing.T) {
+	album, lyricDir := t.TempDir(), t.TempDir()
+	for _, name := range []string{"Song.flac", "Song.lrc", "Song (Live).flac"} {
+		if err := os.WriteFile(filepath.Join(album, name), []byte("[00:01.00]a\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	track := Track{Path: filepath.Join(album, "Song (Live).flac"), Title: "Song (Live)", Artists: []string{"Artist"}}
+	if match, ok := (LyricSearch{}).Match(track); ok {
+		t.Errorf("matched %s, the lyric file of another track", match.Path)
+	}
+	//A lyric file of its own in the album directory and a file in a lyric directory are still found.
+	//专辑目录中不属于其他音频文件的歌词文件以及歌词目录中的文件仍会被找到。
+	own := filepath.Join(album, "Artist - Song (Live).lrc")
+	if err := os.WriteFile(own, []byte("[00:01.00]a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if match, ok := (LyricSearch{}).Match(track); !ok || match.Path != own {
+		t.Errorf("Match = %+v, %v, want %s", match, ok, own)
+	}
+	if err := os.Remove(own); err != nil {
+		t.Fatal(err)
+	}
+	searched := filepath.Join(lyricDir, "Artist", "Song.lrc")
+	if err := os.MkdirAll(filepath.Dir(searched), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(searched, []byte("[00:01.00]a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if match, ok := (LyricSearch{Dirs: []string{lyricDir}}).Match(track); !ok || match.Path != searched {
+		t.Errorf("Match = %+v, %v, want %s", match, ok, searched)
+	}
+}
@@ -0,0 +1,168 @@
+package lyrics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultProviderOrder The providers tried when none are configured, the http provider has to be enabled explicitly.
+// 未配置时尝试的提供者，http提供者需要显式启用。
+var DefaultProviderOrder = []string{"sidecar", "search", "player", "embedded"}
+
+// LyricProvider A source of lyrics for a track, such as the lyric file next to the audio file or a lyrics server.
+// 曲目歌词的来源，例如音频文件旁的歌词文件或歌词服务器。
+type LyricProvider interface {
+
+	// Name
+	// 提供者名称，用于配置和日志
+	Name() string
+
+	// Lyric
+	// 获取曲目的歌词，duration为歌曲时长（微妙）。没有歌词时返回ErrNoLyrics
+	Lyric(track Track, duration uint64) (*LyricResult, error)
+}
+
+// LyricResult The lyrics a provider found and where they came from.
+// 提供者找到的歌词及其来源。
+type LyricResult struct {
+	Lyric       *Lyric
+	Diagnostics []Diagnostic
+	Source      string //The file or URL the lyrics were read from, for logs. 读取歌词的文件或URL，用于日志。
+	Path        string //The lyric file, empty if the lyrics do not come from a lyric file. 歌词文件，歌词不是来自歌词文件时为空。
+}
+
+// ProviderOptions The settings of the providers created by NewProviders.
+// NewProviders所创建的提供者的设置。
+type ProviderOptions struct {
+	Encoding string      //The encoding of lyric files, empty means automatic detection. 歌词文件的编码，为空表示自动检测。
+	Search   LyricSearch //The search directories, templates and fuzzy matching of the search provider. search提供者的搜索目录、模板和模糊匹配。
+	BaseURL  string      //The base URL of the LRCLIB-compatible server of the http provider. http提供者所用LRCLIB兼容服务器的基础URL。
+	Cache    *LyricCache //The cache of the embedded and http providers, nil means no cache. embedded和http提供者的缓存，nil表示不使用缓存。
+}
+
+// NewProviders Create the providers with the given names in order: sidecar, search, player, embedded and http.
+// The embedded and http providers are wrapped in a CachedProvider when ProviderOptions.Cache is set.
+// 按顺序创建指定名称的提供者：sidecar、search、player、embedded和http。设置了ProviderOptions.Cache时，embedded和http提供者会被CachedProvider包装。
+func NewProviders(names []string, options ProviderOptions) ([]LyricProvider, error) {
+	providers := make([]LyricProvider, 0, len(names))
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "sidecar":
+			providers = append(providers, SidecarProvider{Encoding: options.Encoding})
+		case "search":
+			providers = append(providers, SearchProvider{Search: options.Search, Encoding: options.Encoding})
+		case "player":
+			providers = append(providers, PlayerProvider{})
+		case "embedded":
+			providers = append(providers, options.cached(EmbeddedProvider{}))
+		case "http":
+			providers = append(providers, options.cached(NewHTTPProvider(options.BaseURL)))
+		default:
+			return nil, fmt.Errorf("unknown lyric provider %q, expected sidecar, search, player, embedded or http", name)
+		}
+	}
+	return providers, nil
+}
+
+// cached Wrap the provider in a CachedProvider if there is a cache.
+// 如果有缓存，则用CachedProvider包装提供者。
+func (options ProviderOptions) cached(provider LyricProvider) LyricProvider {
+	if options.Cache == nil {
+		return provider
+	}
+	return CachedProvider{Provider: provider, Cache: options.Cache}
+}
+
+// SidecarProvider Reads the lyric file next to the audio file, for example a.flac matches a.lrc.
+// 读取音频文件旁的歌词文件，例如a.flac对应a.lrc。
+type SidecarProvider struct {
+	Encoding string
+}
+
+func (provider SidecarProvider) Name() string {
+	return "sidecar"
+}
+
+func (provider SidecarProvider) Lyric(track Track, duration uint64) (*LyricResult, error) {
+	if track.Path == "" {
+		return nil, ErrNoLyrics
+	}
+	lyricPath := findLyricFile(track.Path)
+	if lyricPath == "" {
+		return nil, ErrNoLyrics
+	}
+	return readLyricFile(lyricPath, lyricPath, duration, provider.Encoding)
+}
+
+// SearchProvider Looks for the lyric file in the search directories with the filename templates, then by fuzzy matching.
+// 使用文件名模板在搜索目录中查找歌词文件，然后进行模糊匹配。
+type SearchProvider struct {
+	Search   LyricSearch
+	Encoding string
+}
+
+func (provider SearchProvider) Name() string {
+	return "search"
+}
+
+func (provider SearchProvider) Lyric(track Track, duration uint64) (*LyricResult, error) {
+	if lyricPath := provider.Search.Find(track); lyricPath != "" {
+		return readLyricFile(lyricPath, lyricPath, duration, provider.Encoding)
+	}
+	if track.Title == "" {
+		return nil, ErrNoLyrics
+	}
+	match, ok := provider.Search.Match(track)
+	if !ok {
+		return nil, ErrNoLyrics
+	}
+	return readLyricFile(match.Path, fmt.Sprintf("%s (fuzzy match, score %.2f)", match.Path, match.Score), duration, provider.Encoding)
+}
+
+// PlayerProvider Uses the lyrics the player puts in the xesam:asText metadata.
+// 使用播放器放在xesam:asText元数据中的歌词。
+type PlayerProvider struct{}
+
+func (provider PlayerProvider) Name() string {
+	return "player"
+}
+
+func (provider PlayerProvider) Lyric(track Track, duration uint64) (*LyricResult, error) {
+	if track.Text == "" {
+		return nil, ErrNoLyrics
+	}
+	lyric, diagnostics, err := NewLyricFromText(track.Text, duration, ParseOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &LyricResult{Lyric: lyric, Diagnostics: diagnostics, Source: "xesam:asText"}, nil
+}
+
+// EmbeddedProvider Reads the lyrics embedded in the tags of the audio file.
+// 读取音频文件标签中内嵌的歌词。
+type EmbeddedProvider struct{}
+
+func (provider EmbeddedProvider) Name() string {
+	return "embedded"
+}
+
+func (provider EmbeddedProvider) Lyric(track Track, duration uint64) (*LyricResult, error) {
+	if track.Path == "" {
+		return nil, ErrNoLyrics
+	}
+	lyric, diagnostics, err := NewLyricFromAudioTags(track.Path, duration, ParseOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &LyricResult{Lyric: lyric, Diagnostics: diagnostics, Source: "embedded lyrics of " + track.Path}, nil
+}
+
+// readLyricFile Parse a lyric file found by a provider without the strict mode, source describes it for logs.
+// 以非严格模式解析提供者找到的歌词文件，source用于在日志中描述该文件。
+func readLyricFile(path, source string, duration uint64, encoding string) (*LyricResult, error) {
+	lyric, diagnostics, err := NewLyricWithDiagnostics(path, duration, ParseOptions{Encoding: encoding})
+	if err != nil {
+		return &LyricResult{Diagnostics: diagnostics, Source: source, Path: path}, err
+	}
+	return &LyricResult{Lyric: lyric, Diagnostics: diagnostics, Source: source, Path: path}, nil
+}
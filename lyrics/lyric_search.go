@@ -0,0 +1,166 @@
+package lyrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var templateFieldRegex = regexp.MustCompile(`\{([a-z]+)}`)
+
+// DefaultLyricTemplates The filename templates used in the search directories when none are given.
+// 未指定时在搜索目录中使用的文件名模板。
+var DefaultLyricTemplates = []string{"{basename}", "{artist} - {title}", "{artist}/{title}", "{artist}/{album}/{title}", "{title}"}
+
+// LyricSearch Where lyric files are looked up besides the directory of the audio file.
+// Templates are paths relative to each directory without the extension, with the fields {artist}, {album}, {title},
+// {track} (two digits) and {basename} (the audio file name without its extension). Templates using a field the track
+// does not have are skipped, and file names are compared case-insensitively.
+// 除音频文件所在目录外查找歌词文件的位置。模板为相对于各目录且不含扩展名的路径，可使用{artist}、{album}、{title}、{track}（两位数字）
+// 和{basename}（不含扩展名的音频文件名）字段。使用了曲目所没有的字段的模板会被跳过，文件名比较不区分大小写。
+type LyricSearch struct {
+	Dirs      []string
+	Templates []string //Empty means DefaultLyricTemplates. 为空表示使用DefaultLyricTemplates。
+	Threshold float64  //The lowest score of a fuzzy match, 0 means DefaultMatchThreshold. 模糊匹配的最低分数，0表示DefaultMatchThreshold。
+}
+
+// CheckLyricTemplate Check that the template only uses known fields.
+// 检查模板是否只使用了已知的字段。
+func CheckLyricTemplate(template string) error {
+	matches := templateFieldRegex.FindAllStringSubmatch(template, -1)
+	if len(matches) == 0 {
+		return fmt.Errorf("template %q uses no fields", template)
+	}
+	for _, match := range matches {
+		switch match[1] {
+		case "artist", "album", "title", "track", "basename":
+		default:
+			return fmt.Errorf("unknown field {%s} in template %q", match[1], template)
+		}
+	}
+	return nil
+}
+
+// Find Find the lyric file of the track in each directory with each template, returns an empty string if there is none.
+// 在每个目录中依次使用每个模板查找曲目的歌词文件，没有则返回空字符串。
+func (search LyricSearch) Find(track Track) string {
+	templates := search.Templates
+	if len(templates) == 0 {
+		templates = DefaultLyricTemplates
+	}
+	for _, dir := range search.Dirs {
+		for _, template := range templates {
+			name, ok := track.expand(template)
+			if !ok {
+				continue
+			}
+			for _, ext := range LyricExtensions {
+				if lyricPath := findFold(expandHome(dir), name+ext); lyricPath != "" {
+					return lyricPath
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// expand Fill the fields of the template, returns false if the track lacks one of them.
+// 填充模板中的字段，曲目缺少其中某个字段时返回false。
+func (track Track) expand(template string) (string, bool) {
+	ok := true
+	name := templateFieldRegex.ReplaceAllStringFunc(template, func(field string) string {
+		var value string
+		switch field {
+		case "{artist}":
+			if len(track.Artists) > 0 {
+				value = track.Artists[0]
+			}
+		case "{album}":
+			value = track.Album
+		case "{title}":
+			value = track.Title
+		case "{track}":
+			if track.TrackNumber > 0 {
+				value = fmt.Sprintf("%02d", track.TrackNumber)
+			}
+		case "{basename}":
+			if track.Path != "" {
+				value = strings.TrimSuffix(filepath.Base(track.Path), filepath.Ext(track.Path))
+			}
+		}
+		//A value is a single path component, a slash in the title must not open a directory.
+		//值是单个路径组成部分，标题中的斜杠不能打开目录。
+		value = strings.TrimSpace(strings.ReplaceAll(value, "/", "_"))
+		if value == "" {
+			ok = false
+		}
+		return value
+	})
+	return name, ok
+}
+
+// findFold Find the file at the relative path inside dir, each path component is compared case-insensitively.
+// Returns an empty string if there is none.
+// 在dir中查找相对路径对应的文件，路径的每个组成部分都不区分大小写地比较。没有则返回空字符串。
+func findFold(dir, rel string) string {
+	current := dir
+	for _, part := range strings.Split(rel, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		exact := filepath.Join(current, part)
+		if _, err := os.Stat(exact); err == nil {
+			current = exact
+			continue
+		}
+		entries, err := os.ReadDir(current)
+		if err != nil {
+			return ""
+		}
+		found := ""
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Name(), part) {
+				found = entry.Name()
+				break
+			}
+		}
+		if found == "" {
+			return ""
+		}
+		current = filepath.Join(current, found)
+	}
+	if info, err := os.Stat(current); err != nil || info.IsDir() {
+		return ""
+	}
+	return current
+}
+
+// expandHome Replace a leading ~ with the home directory of the user.
+// 将开头的~替换为用户的主目录。
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// String Describe the directories and templates for logs.
+// 描述目录和模板，用于日志。
+func (search LyricSearch) String() string {
+	templates := search.Templates
+	if len(templates) == 0 {
+		templates = DefaultLyricTemplates
+	}
+	quoted := make([]string, len(templates))
+	for i, template := range templates {
+		quoted[i] = strconv.Quote(template)
+	}
+	return fmt.Sprintf("dirs %v, templates %s", search.Dirs, strings.Join(quoted, " "))
+}
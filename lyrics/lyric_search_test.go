@@ -0,0 +1,98 @@
+package lyrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrackExpand(t *testing.T) {
+	track := Track{Path: "/music/01 Song.flac", Title: "AC/DC ", Artists: []string{"Artist", "Other"}, Album: "Album", TrackNumber: 1}
+	tests := []struct {
+		template string
+		want     string
+		ok       bool
+	}{
+		{"{basename}", "01 Song", true},
+		{"{artist}/{album}/{track} {title}", "Artist/Album/01 AC_DC", true},
+		//A slash in a field does not open a directory.
+		//字段中的斜杠不会打开目录。
+		{"{title}", "AC_DC", true},
+		{"{artist} - {title}", "Artist - AC_DC", true},
+	}
+	for _, tt := range tests {
+		if name, ok := track.expand(tt.template); name != tt.want || ok != tt.ok {
+			t.Errorf("expand(%q) = %q, %t, want %q, %t", tt.template, name, ok, tt.want, tt.ok)
+		}
+	}
+	//Templates using a field the track does not have are skipped.
+	//使用了曲目所没有的字段的模板会被跳过。
+	for _, template := range []string{"{album}/{title}", "{track}", "{basename}"} {
+		if _, ok := (Track{Title: "Song"}).expand(template); ok {
+			t.Errorf("expand(%q) accepted a track without the field", template)
+		}
+	}
+}
+
+func TestLyricSearchFind(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"ARTIST/song.LRC", "Artist - Other.ttml", "notes/Song.lrc"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("[00:01.00]Hello\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name   string
+		search LyricSearch
+		track  Track
+		want   string
+	}{
+		{"case-folded directory and file", LyricSearch{Dirs: []string{dir}, Templates: []string{"{artist}/{title}"}},
+			Track{Title: "Song", Artists: []string{"artist"}}, "ARTIST/song.LRC"},
+		{"templates in order", LyricSearch{Dirs: []string{dir}, Templates: []string{"{artist} - {title}", "{artist}/{title}"}},
+			Track{Title: "Other", Artists: []string{"Artist"}}, "Artist - Other.ttml"},
+		{"default templates", LyricSearch{Dirs: []string{dir}},
+			Track{Title: "Song", Artists: []string{"Artist"}}, "ARTIST/song.LRC"},
+		{"a field with a slash", LyricSearch{Dirs: []string{dir}, Templates: []string{"{title}"}},
+			Track{Title: "notes/Song"}, ""},
+		{"no match", LyricSearch{Dirs: []string{dir}},
+			Track{Title: "Missing", Artists: []string{"Artist"}}, ""},
+	}
+	for _, tt := range tests {
+		got := tt.search.Find(tt.track)
+		want := ""
+		if tt.want != "" {
+			want = filepath.Join(dir, tt.want)
+		}
+		if got != want {
+			t.Errorf("%s: Find = %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tests := map[string]string{
+		"~":        home,
+		"~/Lyrics": filepath.Join(home, "Lyrics"),
+		"~other":   "~other",
+		"/lyrics":  "/lyrics",
+	}
+	for path, want := range tests {
+		if got := expandHome(path); got != want {
+			t.Errorf("expandHome(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(home, "Song.lrc"), []byte("[00:01.00]Hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	search := LyricSearch{Dirs: []string{"~"}, Templates: []string{"{title}"}}
+	if got := search.Find(Track{Title: "song"}); got != filepath.Join(home, "Song.lrc") {
+		t.Errorf("Find in ~ = %q", got)
+	}
+}
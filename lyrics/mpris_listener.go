@@ -1,28 +1,41 @@
 package lyrics
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/url"
-	"os"
-	"path/filepath"
-	"strconv"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/godbus/dbus/v5"
-	ffmpeggo "github.com/u2takey/ffmpeg-go"
 )
 
+// mprisBusPrefix The bus names of MPRIS media players start with it.
+// MPRIS媒体播放器的总线名称以此开头。
+const mprisBusPrefix = "org.mpris.MediaPlayer2."
+
 // MPrisListener Media Player Remote Interfacing Specification Listener
 // 媒体播放器远程接口监听器
 type MPrisListener struct {
 	conn          *dbus.Conn
+	signals       chan *dbus.Signal
 	CallBack      MusicEventCallback
-	playing       bool
-	lyric         *Lyric
-	playerBusName string
+	Providers     []LyricProvider         //The providers tried in order, empty means DefaultProviderOrder. 按顺序尝试的提供者，为空表示DefaultProviderOrder。
+	UnsyncedMode  string                  //How unsynchronized lyrics are displayed, UnsyncedScroll (default) or UnsyncedBlock. 非同步歌词的显示方式，UnsyncedScroll（默认）或UnsyncedBlock。
+	Reload        bool                    //Reload the lyric file when it changes on disk. 歌词文件在磁盘上变化时重新加载。
+	Policy        string                  //Which player is shown when several are running, PolicyPlaying (default), PolicyRecent or PolicyPriority. 多个播放器运行时显示哪个播放器，PolicyPlaying（默认）、PolicyRecent或PolicyPriority。
+	Priority      []string                //The players preferred by PolicyPriority in order, such as spotify or org.mpris.MediaPlayer2.vlc. PolicyPriority按顺序优先选择的播放器，例如spotify或org.mpris.MediaPlayer2.vlc。
+	Players       []string                //Glob patterns of the only players whose lyrics are shown, matched against the bus name and the Identity, empty means all players. 仅显示其歌词的播放器的通配符模式，与总线名称和Identity匹配，为空表示所有播放器。
+	IgnorePlayers []string                //Glob patterns of the players whose lyrics are never shown, such as firefox*. 从不显示其歌词的播放器的通配符模式，例如firefox*。
+	mu            sync.Mutex              //Guards players, names, identities and active, which the signal, ticker and file watcher goroutines share. 保护players、names、identities和active，它们由信号、定时器和文件监视协程共享。
+	players       map[string]*playerState //By unique bus name. 按唯一总线名称索引。
+	names         map[string][]string     //The well-known names of the players by unique bus name. 按唯一总线名称索引的播放器公认名称。
+	identities    map[string]string       //The Identity of the players by unique bus name, only read when players are filtered. 按唯一总线名称索引的播放器Identity，仅在过滤播放器时读取。
+	active        *playerState            //The player the lyrics are shown for. 显示歌词的播放器。
+	fileWatcher   *LyricFileWatcher
+	watchMu       sync.Mutex //Guards fileWatcher and the file it watches. 保护fileWatcher及其监视的文件。
 }
 
 // ConnectSessionBus connects to the session bus.
@@ -45,6 +58,23 @@ func (watcher *MPrisListener) ConnectSessionBus(withLog bool) error {
 		}
 		return err
 	}
+	//Players that exit are forgotten, they cannot send a last PlaybackStatus.
+	//退出的播放器会被遗忘，它们无法发送最后的PlaybackStatus。
+	err = conn.AddMatchSignal(
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg0Namespace(strings.TrimSuffix(mprisBusPrefix, ".")),
+	)
+	if err != nil {
+		if withLog {
+			log.Fatal("addMatchSignal failed:", err)
+		}
+		return err
+	}
+	//Signals are received from here on, so that changes while the players are queried at startup are not lost.
+	//从此处开始接收信号，以免在启动时查询播放器期间丢失变化。
+	watcher.signals = make(chan *dbus.Signal, 16)
+	conn.Signal(watcher.signals)
 	if withLog {
 		fmt.Println("Listening for MPris metadata or status changes...")
 	}
@@ -52,28 +82,81 @@ func (watcher *MPrisListener) ConnectSessionBus(withLog bool) error {
 	return nil
 }
 
+// LoadPlayingTrack Look for the players already running on the session bus and show the lyrics of the one the policy
+// selects. Returns false if no player has a track.
+// 查找会话总线上已运行的播放器，并显示策略所选播放器的歌词。没有播放器有曲目时返回false。
+func (watcher *MPrisListener) LoadPlayingTrack(withLog bool) bool {
+	names, err := watcher.playerNames()
+	if err != nil {
+		if withLog {
+			log.Printf("[ERROR] Failed to list the bus names: %v\n", err)
+		}
+		return false
+	}
+	for busName, name := range names {
+		props, err := watcher.playerProperties(busName)
+		if err != nil {
+			if withLog {
+				log.Printf("[WARN] Failed to query the player %s: %v\n", name, err)
+			}
+			continue
+		}
+		if withLog {
+			log.Printf("[DEBUG] Found player %s (%s), status %s\n", name, busName, watcher.extractStatus(props))
+		}
+		watcher.setPlayerProperties(busName, name, watcher.identityOf(busName, withLog), props, withLog)
+	}
+	//All players are known before one is selected, so that the lyrics of the others are not loaded in between.
+	//选择播放器前已知晓所有播放器，以免在此期间加载其他播放器的歌词。
+	watcher.selectionChanged("", withLog)
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+	return watcher.active != nil
+}
+
 // SynchronizedLyrics Synchronized lyrics
 // 同步歌词
 func (watcher *MPrisListener) SynchronizedLyrics(withLog bool, delay uint32) {
 	ticker := time.NewTicker(time.Duration(delay) * time.Millisecond)
 	defer ticker.Stop()
 	for range ticker.C {
-		if !watcher.playing {
+		watcher.mu.Lock()
+		state := watcher.active
+		if state == nil || state.status != "Playing" {
+			watcher.mu.Unlock()
+			continue
+		}
+		busName, lyric := state.busName, state.lyric
+		watcher.mu.Unlock()
+		if lyric != nil && lyric.Unsynced && watcher.UnsyncedMode == UnsyncedBlock {
+			if watcher.CallBack != nil {
+				watcher.CallBack.ShowUnsyncedLyric(busName, lyric)
+			}
 			continue
 		}
-		pos, err := watcher.getPosition()
+		pos, err := watcher.getPosition(busName)
+		now := time.Now()
+		watcher.mu.Lock()
+		if err == nil {
+			state.position, state.positionAt = pos, now
+		} else if estimated, ok := state.estimatedPosition(now); ok {
+			//A player that does not answer in time keeps scrolling from the last position it reported.
+			//未及时响应的播放器从其上次报告的位置继续滚动。
+			pos, err = estimated, nil
+		}
+		watcher.mu.Unlock()
 		if err != nil {
 			if withLog {
 				println("Failed to get playback position:", err.Error())
 			}
 			continue
 		}
-		line, progress := watcher.lyric.LineAt(pos)
-		if withLog {
-			println("[DEBUG] Current lyric line:", line, progress)
+		line, progress, word := lyric.LineAt(pos)
+		if withLog && line != nil {
+			println("[DEBUG] Current lyric line:", line.Text, progress, word)
 		}
 		if watcher.CallBack != nil {
-			watcher.CallBack.UpdateLyric(watcher.playerBusName, line, progress, watcher.lyric)
+			watcher.CallBack.UpdateLyric(busName, line, progress, lyric)
 		}
 	}
 }
@@ -88,13 +171,15 @@ func (watcher *MPrisListener) WatchPlayerEvents(withLog bool) {
 		}
 	}(watcher.conn)
 
-	ch := make(chan *dbus.Signal, 16)
-	watcher.conn.Signal(ch)
 	if withLog {
 		log.Println("Signal channel created, start listening")
 	}
 
-	for sig := range ch {
+	for sig := range watcher.signals {
+		if sig.Name == "org.freedesktop.DBus.NameOwnerChanged" {
+			watcher.handleNameOwnerChanged(sig, withLog)
+			continue
+		}
 		if !watcher.isMarisSignal(sig, withLog) {
 			continue
 		}
@@ -126,86 +211,337 @@ func (watcher *MPrisListener) handleSignal(sig *dbus.Signal, withLog bool) {
 		}
 		return
 	}
+	_, hasMetadata := props["Metadata"]
+	_, hasStatus := props["PlaybackStatus"]
+	if !hasMetadata && !hasStatus {
+		return
+	}
+	watcher.updatePlayer(sig.Sender, props, withLog)
+}
+
+// handleNameOwnerChanged Remember the well-known names of players that start and forget the players that exit.
+// 记住启动的播放器的公认名称，并遗忘退出的播放器。
+func (watcher *MPrisListener) handleNameOwnerChanged(sig *dbus.Signal, withLog bool) {
+	if len(sig.Body) < 3 {
+		return
+	}
+	name, _ := sig.Body[0].(string)
+	oldOwner, _ := sig.Body[1].(string)
+	newOwner, _ := sig.Body[2].(string)
+	if !strings.HasPrefix(name, mprisBusPrefix) {
+		return
+	}
+	watcher.mu.Lock()
+	if watcher.names == nil {
+		watcher.names = map[string][]string{}
+	}
+	if newOwner != "" {
+		if !slices.Contains(watcher.names[newOwner], name) {
+			watcher.names[newOwner] = append(watcher.names[newOwner], name)
+		}
+		if state := watcher.players[newOwner]; state != nil && state.name == "" {
+			state.name = name
+		}
+	}
+	var gone *playerState
+	if oldOwner != "" {
+		//A player may own several names, such as org.mpris.MediaPlayer2.vlc and org.mpris.MediaPlayer2.vlc.instance1234,
+		//it only exited when it owns none of them.
+		//一个播放器可能拥有多个名称，例如org.mpris.MediaPlayer2.vlc和org.mpris.MediaPlayer2.vlc.instance1234，
+		//只有不再拥有其中任何一个时才算退出。
+		owned := slices.DeleteFunc(watcher.names[oldOwner], func(other string) bool { return other == name })
+		if len(owned) > 0 {
+			watcher.names[oldOwner] = owned
+			if state := watcher.players[oldOwner]; state != nil && state.name == name {
+				state.name = owned[0]
+			}
+		} else {
+			delete(watcher.names, oldOwner)
+			delete(watcher.identities, oldOwner)
+			gone = watcher.players[oldOwner]
+			delete(watcher.players, oldOwner)
+		}
+	}
+	watcher.mu.Unlock()
+	if gone != nil {
+		if withLog {
+			log.Printf("[INFO] Player exited: %s\n", name)
+		}
+		watcher.selectionChanged(gone.busName, withLog)
+	}
+}
 
-	if path := watcher.extractLocalPath(props, withLog); path != "" {
-		watcher.onAudioFileChanged(path, sig.Sender, withLog)
+// updatePlayer Apply the changed Metadata and PlaybackStatus properties to the state of the player, then show the
+// lyrics of the player the policy selects.
+// 将变化的Metadata和PlaybackStatus属性应用到播放器的状态，然后显示策略所选播放器的歌词。
+func (watcher *MPrisListener) updatePlayer(busName string, props map[string]dbus.Variant, withLog bool) {
+	state, trackChanged := watcher.setPlayerProperties(busName, watcher.wellKnownName(busName), watcher.identityOf(busName, withLog), props, withLog)
+	watcher.mu.Lock()
+	wasActive := watcher.active == state
+	watcher.mu.Unlock()
+	switch {
+	case !wasActive:
+		watcher.selectionChanged("", withLog)
+	case trackChanged:
+		watcher.loadLyric(state, withLog)
+		watcher.selectionChanged(busName, withLog)
+	case watcher.extractStatus(props) != "":
+		watcher.selectionChanged(busName, withLog)
 	}
+}
 
-	if status := watcher.extractStatus(props); status != "" {
-		watcher.onPlaybackStatusChanged(status, withLog)
+// setPlayerProperties Apply the changed Metadata and PlaybackStatus properties to the state of the player, which is
+// added with the name and the Identity if it is new. Returns whether the track changed.
+// 将变化的Metadata和PlaybackStatus属性应用到播放器的状态，新播放器会连同名称和Identity被添加。返回曲目是否变化。
+func (watcher *MPrisListener) setPlayerProperties(busName string, name string, identity string, props map[string]dbus.Variant, withLog bool) (*playerState, bool) {
+	track, hasTrack := watcher.extractTrack(props, withLog)
+	status := watcher.extractStatus(props)
+	now := time.Now()
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+	if watcher.players == nil {
+		watcher.players = map[string]*playerState{}
+	}
+	state := watcher.players[busName]
+	isNew := state == nil
+	if isNew {
+		state = &playerState{busName: busName, name: name, identity: identity}
+		watcher.players[busName] = state
+	}
+	if status != "" {
+		state.setStatus(status, now)
+	}
+	trackChanged := false
+	if _, ok := props["Metadata"]; ok {
+		trackChanged = state.setTrack(track, hasTrack, now)
+	}
+	if withLog && trackChanged && hasTrack {
+		log.Printf("[DEBUG] Track of %s: %s\n", state.describe(), track.Describe())
+	}
+	if withLog && isNew && !watcher.allowsPlayer(state) {
+		log.Printf("[INFO] Ignoring the player %s, Identity %q\n", state.describe(), identity)
+	}
+	return state, trackChanged
+}
+
+// selectionChanged Select the player again after a change of the player with the bus name, load its lyrics and tell
+// the callback. The callback is told if the selected player is another one or if the changed player is selected.
+// 在指定总线名称的播放器变化后重新选择播放器，加载其歌词并通知回调。所选播放器发生变化或变化的播放器被选中时通知回调。
+func (watcher *MPrisListener) selectionChanged(changed string, withLog bool) {
+	watcher.mu.Lock()
+	previous := watcher.active
+	watcher.active = watcher.selectPlayer()
+	active := watcher.active
+	watcher.mu.Unlock()
+	if active != previous {
+		if active != nil && withLog {
+			log.Printf("[INFO] Showing the lyrics of the player %s\n", active.describe())
+		}
+		if active != nil {
+			watcher.loadLyric(active, withLog)
+		}
+	} else if active == nil || active.busName != changed {
+		return
+	}
+	watcher.watchActiveLyricFile(withLog)
+	if active == nil {
+		if previous != nil {
+			watcher.notify(previous.busName, "Stopped", nil, withLog)
+		}
+		return
 	}
+	watcher.mu.Lock()
+	status, lyric := active.status, active.lyric
+	watcher.mu.Unlock()
+	watcher.notify(active.busName, status, lyric, withLog)
 }
 
-func (watcher *MPrisListener) extractLocalPath(props map[string]dbus.Variant, withLog bool) string {
+// extractTrack Read the track from the changed Metadata property, returns false if it has no local audio file, lyrics or title.
+// 从变化的Metadata属性读取曲目，没有本地音频文件、歌词和标题时返回false。
+func (watcher *MPrisListener) extractTrack(props map[string]dbus.Variant, withLog bool) (Track, bool) {
 	metaVar, ok := props["Metadata"]
 	if !ok {
-		return ""
+		return Track{}, false
 	}
-	meta := metaVar.Value().(map[string]dbus.Variant)
-	urlVar, ok := meta["xesam:url"]
+	meta, ok := metaVar.Value().(map[string]dbus.Variant)
 	if !ok {
-		return ""
-	}
-	urlStr := urlVar.Value().(string)
-	if withLog {
-		log.Printf("[DEBUG] Metadata xesam:url = %s\n", urlStr)
-	}
-	if !strings.HasPrefix(urlStr, "file://") {
-		return ""
-	}
-	local := strings.TrimPrefix(urlStr, "file://")
-	decoded, err := url.PathUnescape(local)
-	if err != nil {
 		if withLog {
-			log.Printf("[ERROR] Failed to decode file path: %v\n", err)
+			log.Println("[WARN] Failed to cast Metadata to map[string]dbus.Variant")
 		}
-		return ""
+		return Track{}, false
 	}
+	track := trackFromMetadata(meta)
 	if withLog {
-		log.Printf("[DEBUG] Decoded file path: %s\n", decoded)
+		log.Printf("[DEBUG] Metadata xesam:url = %s\n", track.URL)
+		if track.Text != "" {
+			log.Printf("[DEBUG] Metadata xesam:asText has %d characters\n", len(track.Text))
+		}
 	}
-	if !isAudioFile(decoded) {
+	if track.Path != "" {
 		if withLog {
-			log.Printf("[DEBUG] File is not recognized audio file: %s\n", decoded)
+			log.Printf("[DEBUG] Decoded file path: %s\n", track.Path)
+		}
+		if !isAudioFile(track.Path) {
+			if withLog {
+				log.Printf("[DEBUG] File is not recognized audio file: %s\n", track.Path)
+			}
+			track.Path = ""
 		}
-		return ""
 	}
-	return decoded
+	return track, track.Path != "" || track.Text != "" || track.Title != ""
 }
 
-func (watcher *MPrisListener) onAudioFileChanged(path, sender string, withLog bool) {
-	watcher.playerBusName = sender
-	lrcPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".lrc"
-	if withLog {
-		log.Printf("[DEBUG] Looking for lyric file: %s\n", lrcPath)
+// loadLyric Start looking up the lyrics of the track of the player, unless they were looked up already. The lookup
+// runs on its own goroutine, as providers such as http may take seconds and the signals of the players must not wait.
+// 开始查找播放器曲目的歌词，已查找过时跳过。查找在单独的协程中进行，因为http等提供者可能需要数秒，而播放器的信号不能等待。
+func (watcher *MPrisListener) loadLyric(state *playerState, withLog bool) {
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+	if state.lyricLoaded || !state.hasTrack {
+		return
 	}
-	if _, err := os.Stat(lrcPath); err != nil {
-		if withLog {
-			log.Printf("[WARN] Lyric file not found: %s\n", lrcPath)
+	state.lyricLoaded = true
+	go watcher.lookUpLyric(state, state.track, state.generation, withLog)
+}
+
+// lookUpLyric Look up the lyrics of the track with the first provider that has them. They are dropped if the player
+// changed track in the meantime.
+// 使用第一个有歌词的提供者查找曲目的歌词。若播放器在此期间切换了曲目，则丢弃查找结果。
+func (watcher *MPrisListener) lookUpLyric(state *playerState, track Track, generation uint64, withLog bool) {
+	//The mpris:length of the player is used when it is known, the audio file is only read otherwise.
+	//已知播放器的mpris:length时使用它，否则才读取音频文件。
+	dur := track.Length
+	if dur == 0 && track.Path != "" {
+		var err error
+		dur, err = SongDuration(track.Path)
+		if err != nil && withLog {
+			log.Printf("[WARN] Failed to get song duration, the end of the last line is estimated: %v\n", err)
 		}
-		return
 	}
-	err := watcher.getAllProperties()
-	if err != nil {
+	providers := watcher.Providers
+	if len(providers) == 0 {
+		providers, _ = NewProviders(DefaultProviderOrder, ProviderOptions{})
+	}
+	for _, provider := range providers {
+		result, err := provider.Lyric(track, dur)
+		if withLog && result != nil {
+			for _, d := range result.Diagnostics {
+				log.Printf("[DEBUG] %s\n", d.Error())
+			}
+		}
+		if errors.Is(err, ErrNoLyrics) {
+			if withLog {
+				log.Printf("[DEBUG] The %s provider has no lyrics for: %s\n", provider.Name(), track.Describe())
+			}
+			continue
+		}
+		if err != nil {
+			if withLog {
+				log.Printf("[ERROR] The %s provider failed: %v\n", provider.Name(), err)
+			}
+			continue
+		}
+		watcher.mu.Lock()
+		current := state.generation == generation
+		if current {
+			state.lyric, state.lyricPath = result.Lyric, result.Path
+			state.loaded = loadedLyric{provider: provider, track: track, duration: dur}
+		}
+		active := watcher.active == state
+		watcher.mu.Unlock()
+		if !current {
+			if withLog {
+				log.Printf("[DEBUG] The track changed while its lyrics were looked up, dropping them: %s\n", track.Describe())
+			}
+			return
+		}
 		if withLog {
-			log.Printf("[ERROR] Failed get all properties: %v\n", err)
+			log.Printf("[INFO] Loaded lyrics from the %s provider: %s\n", provider.Name(), result.Source)
+		}
+		if active {
+			watcher.watchActiveLyricFile(withLog)
 		}
 		return
 	}
-	dur, err := watcher.getSongDuration(path)
-	if err != nil {
-		if withLog {
-			log.Printf("[ERROR] Failed to get song duration: %v\n", err)
+	if withLog {
+		log.Printf("[WARN] Lyric file not found for: %s\n", track.Describe())
+	}
+}
+
+// watchActiveLyricFile Watch the lyric file of the selected player if Reload is set, nothing is watched if it has none.
+// watchMu makes the last caller win, whether it is a change of the selected player or a lookup that finished.
+// 设置了Reload时监视所选播放器的歌词文件，没有歌词文件时不监视。watchMu确保最后的调用者生效，无论是所选播放器的变化还是完成的查找。
+func (watcher *MPrisListener) watchActiveLyricFile(withLog bool) {
+	if !watcher.Reload {
+		return
+	}
+	watcher.watchMu.Lock()
+	defer watcher.watchMu.Unlock()
+	watcher.mu.Lock()
+	path := ""
+	if watcher.active != nil {
+		path = watcher.active.lyricPath
+	}
+	watcher.mu.Unlock()
+	watcher.watchLyricFile(path, withLog)
+}
+
+// watchLyricFile Watch the lyric file, an empty path stops watching.
+// 监视歌词文件，路径为空时停止监视。
+func (watcher *MPrisListener) watchLyricFile(path string, withLog bool) {
+	if watcher.fileWatcher == nil {
+		if path == "" {
+			return
 		}
+		fileWatcher, err := NewLyricFileWatcher(func(path string) {
+			watcher.reloadLyric(path, withLog)
+		})
+		if err != nil {
+			if withLog {
+				log.Printf("[WARN] Failed to watch lyric files, they are not reloaded when they change: %v\n", err)
+			}
+			return
+		}
+		watcher.fileWatcher = fileWatcher
+		go fileWatcher.Run(withLog)
+	}
+	if err := watcher.fileWatcher.Watch(path); err != nil && withLog {
+		log.Printf("[WARN] Failed to watch the lyric file %s: %v\n", path, err)
+	}
+}
+
+// reloadLyric Load the lyrics of the selected player again with the same provider after the lyric file changed.
+// The previous lyrics are kept if the file cannot be read, for example while it is only partly saved.
+// 歌词文件变化后，使用同一提供者重新加载所选播放器的歌词。文件无法读取时（例如只保存了一部分）保留之前的歌词。
+func (watcher *MPrisListener) reloadLyric(path string, withLog bool) {
+	watcher.mu.Lock()
+	state := watcher.active
+	if state == nil || state.lyricPath != path || state.loaded.provider == nil {
+		watcher.mu.Unlock()
 		return
 	}
-	watcher.lyric, err = NewLyric(lrcPath, dur)
+	loaded, generation := state.loaded, state.generation
+	watcher.mu.Unlock()
+	result, err := loaded.provider.Lyric(loaded.track, loaded.duration)
+	if withLog && result != nil {
+		for _, d := range result.Diagnostics {
+			log.Printf("[DEBUG] %s\n", d.Error())
+		}
+	}
 	if err != nil {
 		if withLog {
-			log.Printf("[ERROR] Failed to parse lyric file %s: %v\n", lrcPath, err)
+			log.Printf("[WARN] Failed to reload %s, keeping the previous lyrics: %v\n", path, err)
 		}
-	} else if withLog {
-		log.Printf("[INFO] Loaded lyric file: %s\n", lrcPath)
+		return
+	}
+	watcher.mu.Lock()
+	defer watcher.mu.Unlock()
+	if state.generation != generation {
+		return
+	}
+	state.lyric, state.lyricPath = result.Lyric, result.Path
+	if withLog {
+		log.Printf("[INFO] Reloaded lyrics: %s\n", result.Source)
 	}
 }
 
@@ -214,38 +550,36 @@ func (watcher *MPrisListener) extractStatus(props map[string]dbus.Variant) strin
 	if !ok {
 		return ""
 	}
-	return sv.Value().(string)
+	status, _ := sv.Value().(string)
+	return status
 }
 
-func (watcher *MPrisListener) onPlaybackStatusChanged(status string, withLog bool) {
-	if watcher.playerBusName == "" {
-		return
-	}
+// notify Tell the callback the playback status of the selected player.
+// 将所选播放器的播放状态通知回调。
+func (watcher *MPrisListener) notify(busName string, status string, lyric *Lyric, withLog bool) {
 	switch status {
 	case "Playing":
-		watcher.playing = true
 		if withLog {
-			log.Printf("[INFO] Triggering Play callback for bus: %s\n", watcher.playerBusName)
+			log.Printf("[INFO] Triggering Play callback for bus: %s\n", busName)
 		}
 		if watcher.CallBack != nil {
-			watcher.CallBack.Play(watcher.playerBusName, "", watcher.lyric)
+			watcher.CallBack.Play(busName, "", lyric)
 		}
 	case "Stopped":
-		watcher.playing = false
 		if withLog {
-			log.Printf("[INFO] Triggering Stop callback for bus: %s\n", watcher.playerBusName)
+			log.Printf("[INFO] Triggering Stop callback for bus: %s\n", busName)
 		}
 		if watcher.CallBack != nil {
-			watcher.CallBack.Stop(watcher.playerBusName, "", watcher.lyric)
+			watcher.CallBack.Stop(busName, "", lyric)
 		}
 	case "Paused":
-		watcher.playing = false
 		if withLog {
-			log.Printf("[INFO] Triggering Paused callback for bus: %s\n", watcher.playerBusName)
+			log.Printf("[INFO] Triggering Paused callback for bus: %s\n", busName)
 		}
 		if watcher.CallBack != nil {
-			watcher.CallBack.Paused(watcher.playerBusName, "", watcher.lyric)
+			watcher.CallBack.Paused(busName, "", lyric)
 		}
+	case "":
 	default:
 		if withLog {
 			log.Printf("[WARN] Unknown playback status: %s\n", status)
@@ -253,52 +587,118 @@ func (watcher *MPrisListener) onPlaybackStatusChanged(status string, withLog boo
 	}
 }
 
-func (watcher *MPrisListener) getAllProperties() error {
-	if watcher.playerBusName == "" {
-		return fmt.Errorf("no player bus name set")
-	}
-	obj := watcher.conn.Object(watcher.playerBusName, "/org/mpris/MediaPlayer2")
-	var properties map[string]dbus.Variant
-	err := obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, "org.mpris.MediaPlayer2.Player").Store(&properties)
+// playerNames Get the MPRIS players on the session bus, the first of their well-known names by unique bus name.
+// 获取会话总线上的MPRIS播放器，即按唯一总线名称索引的第一个公认名称。
+func (watcher *MPrisListener) playerNames() (map[string]string, error) {
+	var names []string
+	err := watcher.conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names)
 	if err != nil {
-		return fmt.Errorf("failed to get all properties: %v", err)
+		return nil, err
 	}
-	return nil
+	owned := map[string][]string{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, mprisBusPrefix) {
+			continue
+		}
+		var owner string
+		if err := watcher.conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, name).Store(&owner); err != nil {
+			continue
+		}
+		owned[owner] = append(owned[owner], name)
+	}
+	players := map[string]string{}
+	watcher.mu.Lock()
+	if watcher.names == nil {
+		watcher.names = map[string][]string{}
+	}
+	for owner, names := range owned {
+		watcher.names[owner] = names
+		players[owner] = names[0]
+	}
+	watcher.mu.Unlock()
+	return players, nil
 }
 
-// 获取音频文件时长（单位：微秒）
-func (watcher *MPrisListener) getSongDuration(audioFilePath string) (uint64, error) {
-	// 使用 ffmpeg-go 的 Probe 方法获取音频文件的元数据，返回 JSON 格式的数据
-	output, err := ffmpeggo.Probe(audioFilePath)
-	if err != nil {
-		return 0, fmt.Errorf("failed to probe the audio file: %v", err)
+// wellKnownName Get the well-known name of the player with the unique bus name, the names on the bus are listed again
+// if it is not known yet.
+// 获取具有指定唯一总线名称的播放器的公认名称，尚未知晓时重新列出总线上的名称。
+func (watcher *MPrisListener) wellKnownName(busName string) string {
+	watcher.mu.Lock()
+	names, ok := watcher.names[busName]
+	watcher.mu.Unlock()
+	if ok {
+		if len(names) == 0 {
+			return ""
+		}
+		return names[0]
 	}
-
-	// 解析 JSON 数据
-	var metadata map[string]interface{}
-	err = json.Unmarshal([]byte(output), &metadata)
+	players, err := watcher.playerNames()
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse ffmpeg probe output: %v", err)
+		return ""
+	}
+	//A sender without a name is remembered as well, the names are not listed again for each of its signals.
+	//没有名称的发送者也会被记住，不会为其每个信号重新列出名称。
+	watcher.mu.Lock()
+	if _, ok := watcher.names[busName]; !ok {
+		watcher.names[busName] = nil
 	}
+	watcher.mu.Unlock()
+	return players[busName]
+}
 
-	// 从 "format" 键中获取音频文件的时长信息
-	format := metadata["format"].(map[string]interface{})
-	durationStr := format["duration"].(string)
+// identityOf Get the Identity of the player with the unique bus name when players are filtered, it is only read once
+// as it does not change.
+// 过滤播放器时获取具有指定唯一总线名称的播放器的Identity，由于它不会变化，只读取一次。
+func (watcher *MPrisListener) identityOf(busName string, withLog bool) string {
+	if len(watcher.Players) == 0 && len(watcher.IgnorePlayers) == 0 {
+		return ""
+	}
+	watcher.mu.Lock()
+	identity, ok := watcher.identities[busName]
+	watcher.mu.Unlock()
+	if ok {
+		return identity
+	}
+	identity, err := watcher.playerIdentity(busName)
+	if err != nil && withLog {
+		log.Printf("[WARN] Failed to get the Identity of %s: %v\n", busName, err)
+	}
+	watcher.mu.Lock()
+	if watcher.identities == nil {
+		watcher.identities = map[string]string{}
+	}
+	watcher.identities[busName] = identity
+	watcher.mu.Unlock()
+	return identity
+}
 
-	// 将时长转换为 float64 类型的秒数
-	duration, err := strconv.ParseFloat(durationStr, 64)
+// playerIdentity Get the Identity property of the player, the name it shows to users such as VLC media player.
+// 获取播放器的Identity属性，即其向用户显示的名称，例如VLC media player。
+func (watcher *MPrisListener) playerIdentity(busName string) (string, error) {
+	obj := watcher.conn.Object(busName, "/org/mpris/MediaPlayer2")
+	v, err := obj.GetProperty("org.mpris.MediaPlayer2.Identity")
 	if err != nil {
-		return 0, fmt.Errorf("failed to convert duration to float: %v", err)
+		return "", err
 	}
+	identity, _ := v.Value().(string)
+	return identity, nil
+}
 
-	// 将时长从秒转换为微秒
-	durationInMicroseconds := uint64(duration * 1_000_000)
-	return durationInMicroseconds, nil
+// playerProperties Get the properties of the player interface of the player, such as Metadata and PlaybackStatus.
+// 获取播放器的Player接口属性，例如Metadata和PlaybackStatus。
+func (watcher *MPrisListener) playerProperties(busName string) (map[string]dbus.Variant, error) {
+	obj := watcher.conn.Object(busName, "/org/mpris/MediaPlayer2")
+	var properties map[string]dbus.Variant
+	err := obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, "org.mpris.MediaPlayer2.Player").Store(&properties)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all properties: %v", err)
+	}
+	return properties, nil
 }
 
 // 获取当前音乐的部分位置（微妙us，错误）
-func (watcher *MPrisListener) getPosition() (uint64, error) {
-	obj := watcher.conn.Object(watcher.playerBusName, "/org/mpris/MediaPlayer2")
+func (watcher *MPrisListener) getPosition(busName string) (uint64, error) {
+	obj := watcher.conn.Object(busName, "/org/mpris/MediaPlayer2")
 	var variant dbus.Variant
 	err := obj.Call("org.freedesktop.DBus.Properties.Get", 0,
 		"org.mpris.MediaPlayer2.Player", "Position").Store(&variant)
@@ -0,0 +1,36 @@
+package lyrics
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestHandleNameOwnerChanged(t *testing.T) {
+	state := &playerState{busName: ":1.5", name: "org.mpris.MediaPlayer2.vlc", hasTrack: true, status: "Playing"}
+	watcher := &MPrisListener{
+		players: map[string]*playerState{":1.5": state},
+		names:   map[string][]string{":1.5": {"org.mpris.MediaPlayer2.vlc", "org.mpris.MediaPlayer2.vlc.instance5"}},
+		active:  state,
+	}
+	lost := func(name string) {
+		watcher.handleNameOwnerChanged(&dbus.Signal{Body: []interface{}{name, ":1.5", ""}}, false)
+	}
+	//The player still owns its other name.
+	//播放器仍拥有其另一个名称。
+	lost("org.mpris.MediaPlayer2.vlc")
+	if watcher.players[":1.5"] == nil || watcher.active != state {
+		t.Fatal("the player was forgotten while it owns another name")
+	}
+	if state.name != "org.mpris.MediaPlayer2.vlc.instance5" || !slices.Equal(watcher.names[":1.5"], []string{"org.mpris.MediaPlayer2.vlc.instance5"}) {
+		t.Errorf("name = %q, names = %v, want the remaining name", state.name, watcher.names[":1.5"])
+	}
+	lost("org.mpris.MediaPlayer2.vlc.instance5")
+	if watcher.players[":1.5"] != nil || watcher.active != nil {
+		t.Error("the player was kept after it lost all of its names")
+	}
+	if _, ok := watcher.names[":1.5"]; ok {
+		t.Error("the names of the exited player were kept")
+	}
+}
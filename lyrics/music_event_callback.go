@@ -17,6 +17,10 @@ type MusicEventCallback interface {
 	Paused(playerBusName string, audioFilePath string, lyric *Lyric)
 
 	// UpdateLyric
-	// 当需要更新歌词时
-	UpdateLyric(playerBusName string, line string, progress float64, lyric *Lyric)
+	// 当需要更新歌词时，line在第一行歌词之前为nil
+	UpdateLyric(playerBusName string, line *LyricLine, progress float64, lyric *Lyric)
+
+	// ShowUnsyncedLyric
+	// 以整块方式显示没有时间信息的歌词时
+	ShowUnsyncedLyric(playerBusName string, lyric *Lyric)
 }
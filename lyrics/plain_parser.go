@@ -0,0 +1,68 @@
+package lyrics
+
+import (
+	"strings"
+)
+
+// unsyncedLineUs The time given to each line of unsynchronized lyrics when the duration of the song is unknown.
+// 歌曲时长未知时，为非同步歌词的每一行分配的时间。
+const unsyncedLineUs = 4_000_000
+
+// UnsyncedScroll and UnsyncedBlock The ways unsynchronized lyrics are displayed: scrolled line by line over the song
+// as an estimate, or all lines at once through MusicEventCallback.ShowUnsyncedLyric.
+// 非同步歌词的显示方式：按估算在歌曲中逐行滚动，或者通过MusicEventCallback.ShowUnsyncedLyric一次显示所有行。
+const (
+	UnsyncedScroll = "scroll"
+	UnsyncedBlock  = "block"
+)
+
+// parseText Parse plain text lyrics without timing (.txt).
+// 解析没有时间信息的纯文本歌词(.txt)。
+func parseText(p *lyricParser, data []byte, duration uint64) (*Lyric, error) {
+	text, err := p.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return newUnsyncedLyric(text, duration), nil
+}
+
+// NewLyricFromText Create the lyrics object from text whose format is not known, such as the xesam:asText metadata of a player.
+// Text with LRC time tags is parsed as LRC, other text is treated as unsynchronized lyrics.
+// 从格式未知的文本创建歌词对象，例如播放器的xesam:asText元数据。含有LRC时间标签的文本按LRC解析，其他文本作为非同步歌词处理。
+func NewLyricFromText(text string, duration uint64, options ParseOptions) (*Lyric, []Diagnostic, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil, ErrNoLyrics
+	}
+	options.Encoding = "utf-8"
+	options.Format = ".lrc"
+	return ParseLyric(strings.NewReader(text), "", duration, options)
+}
+
+// newUnsyncedLyric Create unsynchronized lyrics from plain text, the non-empty lines are spread evenly over the song.
+// LRC ID tags such as [ar:] are read into the metadata.
+// 从纯文本创建非同步歌词，非空行均匀分布在歌曲中。[ar:]等LRC ID标签会读取到元数据中。
+func newUnsyncedLyric(text string, duration uint64) *Lyric {
+	var texts []string
+	var metadata Metadata
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if idTag := idTagRegex.FindStringSubmatch(line); idTag != nil {
+			metadata.set(idTag[1], strings.TrimSpace(idTag[2]))
+			continue
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			texts = append(texts, line)
+		}
+	}
+	if duration == 0 {
+		duration = metadata.Length
+	}
+	step := uint64(unsyncedLineUs)
+	if duration > 0 && len(texts) > 0 {
+		step = duration / uint64(len(texts))
+	}
+	lines := make([]LyricLine, len(texts))
+	for i, line := range texts {
+		lines[i] = LyricLine{TimeUs: uint64(i) * step, Text: line}
+	}
+	return &Lyric{Lines: lines, Duration: duration, Metadata: metadata, Unsynced: true}
+}
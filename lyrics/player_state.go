@@ -0,0 +1,194 @@
+package lyrics
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Selection policies of MPrisListener, which player the lyrics are shown for when several players are running.
+// MPrisListener的选择策略，即多个播放器同时运行时显示哪个播放器的歌词。
+const (
+	PolicyPlaying  = "playing"  //A playing player, the most recent of them, or the most recent player if none plays. 正在播放的播放器中最近的一个，没有播放器在播放时选择最近的播放器。
+	PolicyRecent   = "recent"   //The player that most recently started playing, even after it paused. 最近开始播放的播放器，即使它已暂停。
+	PolicyPriority = "priority" //The first player of the priority list, other players as PolicyPlaying. 优先级列表中的第一个播放器，其他播放器按PolicyPlaying选择。
+)
+
+// playerState What is known about one MPRIS player.
+// 关于一个MPRIS播放器的已知信息。
+type playerState struct {
+	busName     string //The unique bus name the signals come from, such as :1.42. 信号来源的唯一总线名称，例如:1.42。
+	name        string //The well-known bus name, such as org.mpris.MediaPlayer2.vlc, empty if unknown. 公认总线名称，例如org.mpris.MediaPlayer2.vlc，未知时为空。
+	identity    string //The Identity property, such as VLC media player, only read when players are filtered. Identity属性，例如VLC media player，仅在过滤播放器时读取。
+	track       Track
+	hasTrack    bool      //Whether the metadata has a local audio file, lyrics or a title. 元数据中是否有本地音频文件、歌词或标题。
+	status      string    //Playing, Paused, Stopped, or empty if unknown. Playing、Paused、Stopped，未知时为空。
+	lastActive  time.Time //When it last started playing or changed track while playing. 最近一次开始播放或在播放时切换曲目的时间。
+	position    uint64    //The last position read, in microseconds. 最近读取的播放位置（微秒）。
+	positionAt  time.Time //When position was read. 读取position的时间。
+	generation  uint64    //Increased on every track change, lyrics loaded for an earlier track are dropped. 每次切换曲目时增加，为之前曲目加载的歌词会被丢弃。
+	lyricLoaded bool      //Whether the lyrics of the track were looked up, this happens once the player is selected. 是否已查找曲目的歌词，播放器被选中时才会查找。
+	lyric       *Lyric
+	lyricPath   string //The lyric file of lyric, empty if it does not come from a file. lyric的歌词文件，不是来自文件时为空。
+	loaded      loadedLyric
+}
+
+// loadedLyric How the lyrics of a player were loaded, so that they can be loaded again when the lyric file changes.
+// 播放器歌词的加载方式，以便在歌词文件变化时重新加载。
+type loadedLyric struct {
+	provider LyricProvider
+	track    Track
+	duration uint64
+}
+
+// setTrack Replace the track of the player, its lyrics are looked up again when it is selected. Returns false if the
+// metadata still describes the same track, as players send it again when only the cover art changes.
+// 替换播放器的曲目，播放器被选中时会重新查找歌词。元数据仍描述同一曲目时返回false，因为播放器在仅封面变化时也会重新发送元数据。
+func (state *playerState) setTrack(track Track, hasTrack bool, now time.Time) bool {
+	if hasTrack == state.hasTrack && sameTrack(track, state.track) {
+		return false
+	}
+	state.track, state.hasTrack = track, hasTrack
+	state.generation++
+	state.lyricLoaded, state.lyric, state.lyricPath, state.loaded = false, nil, "", loadedLyric{}
+	state.position, state.positionAt = 0, time.Time{}
+	if state.status == "Playing" {
+		state.lastActive = now
+	}
+	return true
+}
+
+// setStatus Set the playback status of the player.
+// 设置播放器的播放状态。
+func (state *playerState) setStatus(status string, now time.Time) {
+	if status == "Playing" && state.status != "Playing" {
+		state.lastActive = now
+	}
+	state.status = status
+}
+
+// estimatedPosition Get the position the player has reached since it was last read, false if it was never read.
+// 获取自上次读取以来播放器到达的位置，从未读取时返回false。
+func (state *playerState) estimatedPosition(now time.Time) (uint64, bool) {
+	if state.positionAt.IsZero() {
+		return 0, false
+	}
+	return state.position + uint64(now.Sub(state.positionAt)/time.Microsecond), true
+}
+
+// sameTrack Whether the two tracks have the same file, lyrics, title, artists, album and length.
+// 两个曲目的文件、歌词、标题、歌手、专辑和时长是否相同。
+func sameTrack(a, b Track) bool {
+	return a.URL == b.URL && a.Text == b.Text && a.Title == b.Title && a.Artist() == b.Artist() && a.Album == b.Album && a.Length == b.Length
+}
+
+// CheckPlayerPattern Check that the player pattern is a valid glob pattern.
+// 检查播放器模式是否为有效的通配符模式。
+func CheckPlayerPattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid player pattern %q: %v", pattern, err)
+	}
+	return nil
+}
+
+// matchesPlayer Whether the glob pattern given by the user means the player. It is matched without regard to case
+// against the whole bus name, the part after org.mpris.MediaPlayer2. with and without an instance suffix such as
+// .instance1234, and the Identity of the player, so spotify, firefox*, org.mpris.MediaPlayer2.vlc and "VLC media
+// player" all work.
+// 用户给出的通配符模式是否指该播放器。匹配时忽略大小写，匹配对象为完整的总线名称、org.mpris.MediaPlayer2.之后的部分（带或不带.instance1234等实例后缀）以及播放器的Identity，
+// 因此spotify、firefox*、org.mpris.MediaPlayer2.vlc和"VLC media player"均可使用。
+func matchesPlayer(pattern string, state *playerState) bool {
+	var names []string
+	if strings.HasPrefix(state.name, mprisBusPrefix) {
+		short := strings.TrimPrefix(state.name, mprisBusPrefix)
+		names = append(names, state.name, short)
+		if i := strings.Index(short, ".instance"); i > 0 {
+			names = append(names, short[:i])
+		}
+	}
+	if state.identity != "" {
+		names = append(names, state.identity)
+	}
+	pattern = strings.ToLower(pattern)
+	for _, name := range names {
+		if ok, _ := path.Match(pattern, strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsPlayer Whether the lyrics of the player may be shown, it matches none of IgnorePlayers and one of Players if
+// that is given.
+// 是否可以显示该播放器的歌词，即它不匹配IgnorePlayers中的任何模式，且在给出Players时匹配其中之一。
+func (watcher *MPrisListener) allowsPlayer(state *playerState) bool {
+	for _, pattern := range watcher.IgnorePlayers {
+		if matchesPlayer(pattern, state) {
+			return false
+		}
+	}
+	if len(watcher.Players) == 0 {
+		return true
+	}
+	for _, pattern := range watcher.Players {
+		if matchesPlayer(pattern, state) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectPlayer Choose the player the lyrics are shown for, nil if no allowed player has a track.
+// 选择显示歌词的播放器，没有允许的播放器有曲目时返回nil。
+func (watcher *MPrisListener) selectPlayer() *playerState {
+	var best *playerState
+	for _, state := range watcher.players {
+		if !state.hasTrack || state.status == "Stopped" || !watcher.allowsPlayer(state) {
+			continue
+		}
+		if best == nil || watcher.prefers(state, best) {
+			best = state
+		}
+	}
+	return best
+}
+
+// prefers Whether the policy prefers player a to player b.
+// 策略是否更倾向于播放器a而不是播放器b。
+func (watcher *MPrisListener) prefers(a, b *playerState) bool {
+	if watcher.Policy == PolicyPriority {
+		if rankA, rankB := watcher.priorityRank(a), watcher.priorityRank(b); rankA != rankB {
+			return rankA < rankB
+		}
+	}
+	if watcher.Policy != PolicyRecent {
+		if playingA, playingB := a.status == "Playing", b.status == "Playing"; playingA != playingB {
+			return playingA
+		}
+	}
+	if !a.lastActive.Equal(b.lastActive) {
+		return a.lastActive.After(b.lastActive)
+	}
+	return a.busName < b.busName
+}
+
+// priorityRank Get the position of the player in the priority list, players not in the list come after all others.
+// 获取播放器在优先级列表中的位置，不在列表中的播放器排在最后。
+func (watcher *MPrisListener) priorityRank(state *playerState) int {
+	for i, pattern := range watcher.Priority {
+		if matchesPlayer(pattern, state) {
+			return i
+		}
+	}
+	return len(watcher.Priority)
+}
+
+// describe Describe the player for logs by its well-known name, otherwise by its unique bus name.
+// 在日志中描述播放器，优先使用公认名称，否则使用唯一总线名称。
+func (state *playerState) describe() string {
+	if state.name == "" {
+		return state.busName
+	}
+	return state.name + " (" + state.busName + ")"
+}
@@ -0,0 +1,114 @@
+package lyrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectPlayer(t *testing.T) {
+	now := time.Now()
+	player := func(busName, name, status string, lastActive time.Duration) *playerState {
+		return &playerState{busName: busName, name: mprisBusPrefix + name, hasTrack: true, status: status, lastActive: now.Add(lastActive)}
+	}
+	tests := []struct {
+		name     string
+		policy   string
+		priority []string
+		players  []*playerState
+		want     string
+	}{
+		{"playing before a more recent paused player", PolicyPlaying, nil,
+			[]*playerState{player(":1.1", "vlc", "Playing", -time.Minute), player(":1.2", "spotify", "Paused", 0)}, ":1.1"},
+		{"the most recent of the playing players", PolicyPlaying, nil,
+			[]*playerState{player(":1.1", "vlc", "Playing", -time.Minute), player(":1.2", "spotify", "Playing", 0)}, ":1.2"},
+		{"the most recent player if none plays", PolicyPlaying, nil,
+			[]*playerState{player(":1.1", "vlc", "Paused", -time.Minute), player(":1.2", "spotify", "Paused", 0)}, ":1.2"},
+		{"recent keeps a paused player", PolicyRecent, nil,
+			[]*playerState{player(":1.1", "vlc", "Playing", -time.Minute), player(":1.2", "spotify", "Paused", 0)}, ":1.2"},
+		{"priority before playing", PolicyPriority, []string{"vlc"},
+			[]*playerState{player(":1.1", "vlc", "Paused", -time.Minute), player(":1.2", "spotify", "Playing", 0)}, ":1.1"},
+		{"priority in list order", PolicyPriority, []string{"spotify", "vlc"},
+			[]*playerState{player(":1.1", "vlc", "Playing", 0), player(":1.2", "spotify", "Paused", -time.Minute)}, ":1.2"},
+		{"players not in the priority list as playing", PolicyPriority, []string{"mpv"},
+			[]*playerState{player(":1.1", "vlc", "Paused", 0), player(":1.2", "spotify", "Playing", -time.Minute)}, ":1.2"},
+		{"a tie goes to the first bus name", PolicyPlaying, nil,
+			[]*playerState{player(":1.2", "spotify", "Playing", 0), player(":1.1", "vlc", "Playing", 0)}, ":1.1"},
+		{"stopped players and players without a track are skipped", PolicyPlaying, nil,
+			[]*playerState{player(":1.1", "vlc", "Stopped", 0), {busName: ":1.2", status: "Playing"}}, ""},
+	}
+	for _, tt := range tests {
+		watcher := &MPrisListener{Policy: tt.policy, Priority: tt.priority, players: map[string]*playerState{}}
+		for _, state := range tt.players {
+			watcher.players[state.busName] = state
+		}
+		got := ""
+		if state := watcher.selectPlayer(); state != nil {
+			got = state.busName
+		}
+		if got != tt.want {
+			t.Errorf("%s: selected %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesPlayer(t *testing.T) {
+	vlc := &playerState{busName: ":1.1", name: "org.mpris.MediaPlayer2.vlc.instance1234", identity: "VLC media player"}
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"org.mpris.MediaPlayer2.vlc.instance1234", true},
+		{"org.mpris.MediaPlayer2.vlc*", true},
+		{"vlc.instance1234", true},
+		{"VLC", true},
+		{"vlc media player", true},
+		{"org.mpris.MediaPlayer2.vlc", false},
+		{"spotify", false},
+		{":1.1", false},
+	}
+	for _, tt := range tests {
+		if got := matchesPlayer(tt.pattern, vlc); got != tt.want {
+			t.Errorf("matchesPlayer(%q) = %t, want %t", tt.pattern, got, tt.want)
+		}
+	}
+	//A player without a well-known name is only matched by its Identity.
+	//没有公认名称的播放器只能通过其Identity匹配。
+	if unnamed := (&playerState{busName: ":1.2", identity: "mpv"}); !matchesPlayer("mpv", unnamed) || matchesPlayer("*1.2", unnamed) {
+		t.Error("a player without a well-known name was matched by its unique bus name or not by its Identity")
+	}
+}
+
+func TestAllowsPlayer(t *testing.T) {
+	firefox := &playerState{busName: ":1.1", name: "org.mpris.MediaPlayer2.firefox.instance_1_84", identity: "Mozilla Firefox"}
+	spotify := &playerState{busName: ":1.2", name: "org.mpris.MediaPlayer2.spotify", identity: "Spotify"}
+	tests := []struct {
+		name          string
+		players       []string
+		ignorePlayers []string
+		want          []bool //Whether firefox and spotify are allowed. firefox和spotify是否被允许。
+	}{
+		{"no patterns", nil, nil, []bool{true, true}},
+		{"only players", []string{"spotify"}, nil, []bool{false, true}},
+		{"ignored players", nil, []string{"firefox*"}, []bool{false, true}},
+		{"ignored wins over allowed", []string{"*"}, []string{"mozilla*"}, []bool{false, true}},
+	}
+	for _, tt := range tests {
+		watcher := &MPrisListener{Players: tt.players, IgnorePlayers: tt.ignorePlayers}
+		for i, state := range []*playerState{firefox, spotify} {
+			if got := watcher.allowsPlayer(state); got != tt.want[i] {
+				t.Errorf("%s: allowsPlayer(%s) = %t, want %t", tt.name, state.name, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestCheckPlayerPattern(t *testing.T) {
+	for _, pattern := range []string{"spotify", "firefox*", "org.mpris.MediaPlayer2.vlc", "VLC media player"} {
+		if err := CheckPlayerPattern(pattern); err != nil {
+			t.Errorf("CheckPlayerPattern(%q) = %v", pattern, err)
+		}
+	}
+	if err := CheckPlayerPattern("[vlc"); err == nil {
+		t.Error("CheckPlayerPattern accepted an unclosed bracket")
+	}
+}
@@ -0,0 +1,163 @@
+package lyrics
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	cueTimingRegex    = regexp.MustCompile(`^\s*(\S+)\s+-->\s+(\S+)`)
+	markupTagRegex    = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	overrideTagRegex  = regexp.MustCompile(`\{\\[^}]*}`)
+	vttMetaBlockRegex = regexp.MustCompile(`^(NOTE|STYLE|REGION)(\s|$)`)
+)
+
+// parseSRT Parse lyrics in the SubRip (.srt) format.
+// 解析SubRip(.srt)格式的歌词。
+func parseSRT(p *lyricParser, data []byte, duration uint64) (*Lyric, error) {
+	return parseSubtitle(p, data, duration, false)
+}
+
+// parseVTT Parse lyrics in the WebVTT (.vtt) format, inline timestamps become word-level timing.
+// 解析WebVTT(.vtt)格式的歌词，行内时间戳会转换为逐字时间。
+func parseVTT(p *lyricParser, data []byte, duration uint64) (*Lyric, error) {
+	return parseSubtitle(p, data, duration, true)
+}
+
+// parseSubtitle Parse the cues of a subtitle file, the end of each cue is used as the explicit end of the line.
+// 解析字幕文件中的字幕块，每个字幕块的结束时间作为行的明确结束时间。
+func parseSubtitle(p *lyricParser, data []byte, duration uint64, webVTT bool) (*Lyric, error) {
+	text, err := p.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	rawLines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var lines []LyricLine
+	lineNo := 0
+	for lineNo < len(rawLines) {
+		//Collect one block of non-empty lines, lineNo ends at the line after it.
+		//收集一个由非空行组成的块，lineNo结束于块之后的行。
+		for lineNo < len(rawLines) && strings.TrimSpace(rawLines[lineNo]) == "" {
+			lineNo++
+		}
+		start := lineNo
+		for lineNo < len(rawLines) && strings.TrimSpace(rawLines[lineNo]) != "" {
+			lineNo++
+		}
+		block := rawLines[start:lineNo]
+		if len(block) == 0 {
+			break
+		}
+		if webVTT && start == firstBlock(rawLines) {
+			if !strings.HasPrefix(block[0], "WEBVTT") {
+				if err := p.report(start+1, 1, SeverityError, "WebVTT file must start with WEBVTT"); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if webVTT && vttMetaBlockRegex.MatchString(block[0]) {
+			continue
+		}
+		timing := -1
+		for i, line := range block[:min(2, len(block))] {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			if err := p.report(start+1, 1, SeverityWarning, "block has no cue timing and is ignored"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		timingLineNo := start + timing + 1
+		match := cueTimingRegex.FindStringSubmatch(block[timing])
+		if match == nil {
+			if err := p.report(timingLineNo, 1, SeverityError, "invalid cue timing %q", block[timing]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		startUs, err := parseCueTime(match[1])
+		if err != nil {
+			if err := p.report(timingLineNo, columnOf(block[timing], strings.Index(block[timing], match[1])), SeverityError, "invalid cue start %s: %v", match[1], err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		endUs, err := parseCueTime(match[2])
+		if err != nil {
+			if err := p.report(timingLineNo, columnOf(block[timing], strings.Index(block[timing], match[2])), SeverityError, "invalid cue end %s: %v", match[2], err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if endUs <= startUs {
+			if err := p.report(timingLineNo, 1, SeverityError, "cue ends at %s before it starts at %s", match[2], match[1]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if duration > 0 && startUs > duration {
+			if err := p.report(timingLineNo, 1, SeverityWarning, "cue start %s is past the end of the song", match[1]); err != nil {
+				return nil, err
+			}
+		}
+		cueText := strings.Join(block[timing+1:], " ")
+		cueText = overrideTagRegex.ReplaceAllString(cueText, "")
+		var words []LyricWord
+		if webVTT {
+			cueText = markupTagRegex.ReplaceAllString(cueText, "")
+			var badTag string
+			words, badTag, err = parseWords(cueText, startUs)
+			if err != nil {
+				if err := p.report(timingLineNo+1, 1, SeverityError, "invalid timestamp %s: %v", badTag, err); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if words != nil {
+				for i := range words {
+					words[i].Text = html.UnescapeString(words[i].Text)
+				}
+				cueText = joinWords(words)
+			}
+			cueText = html.UnescapeString(cueText)
+		} else {
+			cueText = markupTagRegex.ReplaceAllString(cueText, "")
+		}
+		cueText = strings.TrimSpace(cueText)
+		if cueText == "" {
+			if err := p.report(timingLineNo, 1, SeverityInfo, "cue has no text"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		lines = append(lines, LyricLine{TimeUs: startUs, EndUs: endUs, Text: cueText, Words: words})
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].TimeUs < lines[j].TimeUs
+	})
+	return &Lyric{Lines: lines, Duration: duration}, nil
+}
+
+// firstBlock Get the index of the first non-empty line.
+// 获取第一个非空行的索引。
+func firstBlock(rawLines []string) int {
+	for i, line := range rawLines {
+		if strings.TrimSpace(line) != "" {
+			return i
+		}
+	}
+	return len(rawLines)
+}
+
+// parseCueTime Parse a cue timestamp such as 00:01:02,500 (SubRip) or 01:02.500 (WebVTT) into microseconds.
+// 将字幕时间戳（例如SubRip的00:01:02,500或WebVTT的01:02.500）解析为微妙。
+func parseCueTime(value string) (uint64, error) {
+	return parseTimestamp(strings.Replace(value, ",", ".", 1))
+}
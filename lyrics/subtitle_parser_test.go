@@ -0,0 +1,77 @@
+package lyrics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCueTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  uint64
+	}{
+		{"00:00:01,500", 1_500_000},
+		{"01:02:03,004", 3_723_004_000},
+		{"01:02.500", 62_500_000},
+		{"00:01:02.500", 62_500_000},
+	}
+	for _, tt := range tests {
+		got, err := parseCueTime(tt.value)
+		if err != nil {
+			t.Errorf("parseCueTime(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCueTime(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseSRT(t *testing.T) {
+	content := "1\r\n00:00:04,000 --> 00:00:05,250\r\n{\\an8}Again\r\n\r\n" +
+		"2\r\n00:00:01,500 --> 00:00:03,000\r\n<i>Hello</i>\r\nworld\r\n\r\n" +
+		"3\r\n00:00:07,000 --> 00:00:06,000\r\nBackwards\r\n"
+	p := &lyricParser{file: "test.srt"}
+	lyric, err := parseSRT(p, []byte(content), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LyricLine{
+		{TimeUs: 1_500_000, EndUs: 3_000_000, Text: "Hello world"},
+		{TimeUs: 4_000_000, EndUs: 5_250_000, Text: "Again"},
+	}
+	if !reflect.DeepEqual(lyric.Lines, want) {
+		t.Errorf("lines = %+v, want %+v", lyric.Lines, want)
+	}
+	if len(p.diagnostics) != 1 || !strings.Contains(p.diagnostics[0].Message, "before it starts") {
+		t.Errorf("diagnostics = %v, want the backwards cue", p.diagnostics)
+	}
+
+	p = &lyricParser{file: "test.srt", options: ParseOptions{Strict: true}}
+	if _, err := parseSRT(p, []byte(content), 0); err == nil {
+		t.Error("strict parsing accepted a cue that ends before it starts")
+	}
+}
+
+func TestParseVTT(t *testing.T) {
+	content := "WEBVTT\n\nNOTE a comment\n\n" +
+		"cue-1\n00:01.000 --> 00:02.000 align:start\n<v Singer>Rock &amp; roll</v>\n\n" +
+		"00:03.000 --> 00:05.000\n<00:03.000>Hel<00:04.000>lo\n"
+	lyric, err := parseVTT(&lyricParser{file: "test.vtt"}, []byte(content), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LyricLine{
+		{TimeUs: 1_000_000, EndUs: 2_000_000, Text: "Rock & roll"},
+		{TimeUs: 3_000_000, EndUs: 5_000_000, Text: "Hello", Words: []LyricWord{{TimeUs: 3_000_000, Text: "Hel"}, {TimeUs: 4_000_000, Text: "lo"}}},
+	}
+	if !reflect.DeepEqual(lyric.Lines, want) {
+		t.Errorf("lines = %+v, want %+v", lyric.Lines, want)
+	}
+
+	p := &lyricParser{file: "test.vtt", options: ParseOptions{Strict: true}}
+	if _, err := parseVTT(p, []byte("00:01.000 --> 00:02.000\nHello\n"), 0); err == nil || !strings.Contains(err.Error(), "must start with WEBVTT") {
+		t.Errorf("got error %v for a file without the WEBVTT header", err)
+	}
+}
@@ -0,0 +1,110 @@
+package lyrics
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Track The track described by the MPRIS metadata of a player.
+// 播放器的MPRIS元数据所描述的曲目。
+type Track struct {
+	URL         string   //xesam:url
+	Path        string   //The local audio file, empty for streams and other non-local tracks. 本地音频文件，流媒体等非本地曲目为空。
+	Title       string   //xesam:title
+	Artists     []string //xesam:artist
+	Album       string   //xesam:album
+	TrackNumber int      //xesam:trackNumber
+	Length      uint64   //mpris:length, with subtle units. mpris:length，单位微妙。
+	Text        string   //xesam:asText, the lyrics provided by the player. xesam:asText，播放器提供的歌词。
+}
+
+// trackFromMetadata Read the track from the MPRIS Metadata property, values of unexpected types are ignored.
+// 从MPRIS的Metadata属性读取曲目，类型不符合预期的值会被忽略。
+func trackFromMetadata(meta map[string]dbus.Variant) Track {
+	track := Track{
+		URL:   variantString(meta["xesam:url"]),
+		Title: variantString(meta["xesam:title"]),
+		Album: variantString(meta["xesam:album"]),
+		Text:  variantString(meta["xesam:asText"]),
+	}
+	if artists, ok := meta["xesam:artist"].Value().([]string); ok {
+		track.Artists = artists
+	} else if artist := variantString(meta["xesam:artist"]); artist != "" {
+		track.Artists = []string{artist}
+	}
+	if number, ok := variantInt(meta["xesam:trackNumber"]); ok && number > 0 {
+		track.TrackNumber = int(number)
+	}
+	if length, ok := variantInt(meta["mpris:length"]); ok && length > 0 {
+		track.Length = uint64(length)
+	}
+	if strings.HasPrefix(track.URL, "file://") {
+		if path, err := url.PathUnescape(strings.TrimPrefix(track.URL, "file://")); err == nil {
+			track.Path = path
+		}
+	}
+	return track
+}
+
+// TrackFromFile Describe a local audio file as a track using its tags, the file name is used as the title if it has none.
+// 使用标签将本地音频文件描述为曲目，没有标题时使用文件名作为标题。
+func TrackFromFile(path string) (Track, error) {
+	track := Track{URL: "file://" + path, Path: path}
+	tags, err := ReadAudioTags(path)
+	if err == nil {
+		track.Title, track.Album, track.TrackNumber = tags.Title, tags.Album, tags.TrackNumber
+		if tags.Artist != "" {
+			track.Artists = []string{tags.Artist}
+		}
+	}
+	if track.Title == "" {
+		track.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return track, err
+}
+
+// Artist Get the artists of the track joined by commas.
+// 获取以逗号连接的曲目歌手。
+func (track Track) Artist() string {
+	return strings.Join(track.Artists, ", ")
+}
+
+// Describe Describe the track for logs by its audio file, otherwise by its artist and title.
+// 用于日志描述曲目，优先使用音频文件，否则使用歌手和标题。
+func (track Track) Describe() string {
+	if track.Path != "" {
+		return track.Path
+	}
+	if track.URL != "" && track.Title == "" {
+		return track.URL
+	}
+	return strings.TrimPrefix(track.Artist()+" - "+track.Title, " - ")
+}
+
+// variantString Get the string held by the variant, empty if it holds another type.
+// 获取变体中的字符串，类型不同时为空。
+func variantString(v dbus.Variant) string {
+	s, _ := v.Value().(string)
+	return s
+}
+
+// variantInt Get the integer held by the variant, players use different integer types for the same property.
+// 获取变体中的整数，不同播放器对同一属性使用不同的整数类型。
+func variantInt(v dbus.Variant) (int64, bool) {
+	switch n := v.Value().(type) {
+	case int32:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,417 @@
+package lyrics
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ttmlTarget The part of a paragraph the text belongs to.
+// 文本所属的段落部分。
+type ttmlTarget int
+
+const (
+	ttmlMain ttmlTarget = iota
+	ttmlBackground
+	ttmlTranslation
+	ttmlRomanization
+)
+
+// ttmlElement An open element and the part of the paragraph its text belongs to.
+// 一个已打开的元素及其文本所属的段落部分。
+type ttmlElement struct {
+	name   string
+	target ttmlTarget
+}
+
+// ttmlParagraph The paragraph (<p>) being parsed.
+// 正在解析的段落(<p>)。
+type ttmlParagraph struct {
+	line        LyricLine
+	key         string
+	lineNo      int
+	column      int
+	plain       strings.Builder
+	translation strings.Builder
+	roman       strings.Builder
+}
+
+// parseTTML Parse lyrics in the TTML format as exported by Apple Music and similar tools.
+// Every <p> becomes a line, timed <span>s become syllables, spans with ttm:role="x-bg" are background vocals
+// and spans with x-translation or x-roman roles, as well as iTunes translations in the head, become the translation and romanization.
+// 解析Apple Music等工具导出的TTML格式歌词。每个<p>成为一行，带时间的<span>成为音节，ttm:role="x-bg"的span为和声，
+// 角色为x-translation或x-roman的span以及头部的iTunes翻译成为翻译和罗马音。
+func parseTTML(p *lyricParser, data []byte, duration uint64) (*Lyric, error) {
+	text, err := p.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	agents := make(map[string]string)
+	translations := make(map[string]string)
+	romanizations := make(map[string]string)
+	var metadata Metadata
+	var lines []LyricLine
+	var keys []string
+	//The begin and end of the paragraph of each line, the syllables are moved by the begin if their times are relative.
+	//每行所属段落的开始和结束时间，音节时间为相对时间时按开始时间平移。
+	var paragraphs [][2]uint64
+	//Apple Music and the tools made for it declare the iTunes namespace and use absolute syllable times.
+	//Apple Music及为其制作的工具会声明iTunes命名空间，并使用绝对的音节时间。
+	appleTiming := false
+	var stack []ttmlElement
+	var para *ttmlParagraph
+	//State of the head: the agent being read and the iTunes translation text being read.
+	//头部的状态：正在读取的歌手以及正在读取的iTunes翻译文本。
+	var agentID, textFor string
+	var headText strings.Builder
+	inTransliteration := false
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line, column := positionOf(text, offset)
+			return nil, p.fail(line, column, "invalid TTML: %v", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			parent := ttmlElement{target: ttmlMain}
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			element := ttmlElement{name: t.Name.Local, target: parent.target}
+			switch t.Name.Local {
+			case "tt":
+				for _, attr := range t.Attr {
+					if attr.Name.Space == "xmlns" && strings.Contains(attr.Value, "music.apple.com") {
+						appleTiming = true
+					}
+				}
+			case "agent":
+				agentID = ttmlAttr(t, "id")
+				agents[agentID] = agentID
+			case "title":
+				headText.Reset()
+			case "transliteration":
+				inTransliteration = true
+			case "text":
+				textFor = ttmlAttr(t, "for")
+				headText.Reset()
+			case "p":
+				line, column := positionOf(text, offset)
+				para = &ttmlParagraph{key: ttmlAttr(t, "key"), lineNo: line, column: column}
+				begin, ok := p.ttmlTime(t, "begin", line, column)
+				if ok {
+					para.line.TimeUs = begin
+				}
+				if end, ok := p.ttmlTime(t, "end", line, column); ok {
+					para.line.EndUs = end
+				}
+				para.line.Agent = ttmlAttr(t, "agent")
+			case "span":
+				switch ttmlAttr(t, "role") {
+				case "x-bg":
+					element.target = ttmlBackground
+				case "x-translation":
+					element.target = ttmlTranslation
+				case "x-roman":
+					element.target = ttmlRomanization
+				}
+				if para == nil || ttmlAttr(t, "begin") == "" {
+					break
+				}
+				line, column := positionOf(text, offset)
+				begin, ok := p.ttmlTime(t, "begin", line, column)
+				if !ok {
+					break
+				}
+				end, _ := p.ttmlTime(t, "end", line, column)
+				word := LyricWord{TimeUs: begin, EndUs: end}
+				switch element.target {
+				case ttmlMain:
+					para.line.Words = append(para.line.Words, word)
+				case ttmlBackground:
+					para.line.Background = append(para.line.Background, word)
+				}
+			case "br":
+				if para != nil {
+					para.appendText(parent, " ")
+				}
+			}
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			switch t.Name.Local {
+			case "agent":
+				agentID = ""
+			case "title":
+				if para == nil && metadata.Title == "" {
+					metadata.Title = strings.TrimSpace(headText.String())
+				}
+			case "transliteration":
+				inTransliteration = false
+			case "text":
+				if textFor != "" {
+					if inTransliteration {
+						romanizations[textFor] = normalizeSpace(headText.String())
+					} else {
+						translations[textFor] = normalizeSpace(headText.String())
+					}
+				}
+				textFor = ""
+			case "p":
+				if para == nil {
+					break
+				}
+				begin, end := para.line.TimeUs, para.line.EndUs
+				line, ok := para.finish()
+				if !ok {
+					if err := p.report(para.lineNo, para.column, SeverityInfo, "paragraph has no text"); err != nil {
+						return nil, err
+					}
+					para = nil
+					break
+				}
+				if duration > 0 && line.TimeUs > duration {
+					if err := p.report(para.lineNo, para.column, SeverityWarning, "paragraph at %s is past the end of the song", formatTimestamp(line.TimeUs)); err != nil {
+						return nil, err
+					}
+				}
+				lines = append(lines, line)
+				keys = append(keys, para.key)
+				paragraphs = append(paragraphs, [2]uint64{begin, end})
+				para = nil
+			}
+		case xml.CharData:
+			switch {
+			case para != nil && len(stack) > 0:
+				para.appendText(stack[len(stack)-1], string(t))
+			case agentID != "" && len(stack) > 0 && stack[len(stack)-1].name == "name":
+				agents[agentID] = strings.TrimSpace(string(t))
+			default:
+				headText.Write(t)
+			}
+		}
+	}
+	relative := !appleTiming && relativeSyllables(lines, paragraphs)
+	for i := range lines {
+		if relative {
+			shiftSyllables(lines[i].Words, paragraphs[i][0])
+			shiftSyllables(lines[i].Background, paragraphs[i][0])
+		}
+		if len(lines[i].Words) > 0 && (lines[i].TimeUs == 0 || lines[i].TimeUs > lines[i].Words[0].TimeUs) {
+			lines[i].TimeUs = lines[i].Words[0].TimeUs
+		}
+		if name, ok := agents[lines[i].Agent]; ok && name != "" {
+			lines[i].Agent = name
+		}
+		if keys[i] == "" {
+			continue
+		}
+		if lines[i].Translation == "" {
+			lines[i].Translation = translations[keys[i]]
+		}
+		if lines[i].Romanization == "" {
+			lines[i].Romanization = romanizations[keys[i]]
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].TimeUs < lines[j].TimeUs
+	})
+	return &Lyric{Lines: lines, Duration: duration, Metadata: metadata}, nil
+}
+
+// appendText Add text to the part of the paragraph the element belongs to. Text of a timed span belongs to its syllable,
+// text between spans is appended to the previous syllable so that the spaces between words are kept.
+// 将文本添加到元素所属的段落部分。带时间的span的文本属于其音节，span之间的文本追加到上一个音节，以保留单词之间的空格。
+func (para *ttmlParagraph) appendText(element ttmlElement, text string) {
+	text = normalizeSpace(text)
+	switch element.target {
+	case ttmlTranslation:
+		para.translation.WriteString(text)
+	case ttmlRomanization:
+		para.roman.WriteString(text)
+	case ttmlBackground:
+		if n := len(para.line.Background); n > 0 {
+			para.line.Background[n-1].Text += text
+		}
+	default:
+		if n := len(para.line.Words); n > 0 {
+			para.line.Words[n-1].Text += text
+		} else {
+			para.plain.WriteString(text)
+		}
+	}
+}
+
+// finish Build the line from the paragraph, returns false if it has no text.
+// 根据段落构建行，没有文本时返回false。
+func (para *ttmlParagraph) finish() (LyricLine, bool) {
+	line := para.line
+	line.Words = trimWords(line.Words)
+	line.Background = trimWords(line.Background)
+	if len(line.Words) > 0 {
+		line.Text = joinWords(line.Words)
+	} else {
+		line.Text = strings.TrimSpace(normalizeSpace(para.plain.String()))
+	}
+	line.Translation = strings.TrimSpace(normalizeSpace(para.translation.String()))
+	line.Romanization = strings.TrimSpace(normalizeSpace(para.roman.String()))
+	return line, line.Text != ""
+}
+
+// relativeSyllables Decide for the whole document whether the syllable times are relative to their paragraph, as in
+// standard TTML. They are relative if a syllable would start before its paragraph or end up after its end when read as
+// absolute times, and absolute if reading them as relative times puts a syllable after the end of its paragraph. When
+// both readings fit, the paragraph ends decide for the standard relative times; without any end the only hint is that
+// no syllable starts before its paragraph, which relative offsets starting at 0 hardly ever do, so they are absolute.
+// 为整个文档判断音节时间是否像标准TTML一样相对于所属段落。按绝对时间读取时若有音节早于其段落开始或晚于其段落结束，则为相对时间；按相对时间读取时若有音节超出其段落的结束时间，则为绝对时间。
+// 两种读法都成立时，有段落结束时间则按标准采用相对时间；没有任何结束时间时，唯一的线索是没有音节早于其段落开始，而从0开始的相对偏移几乎不会如此，因此视为绝对时间。
+func relativeSyllables(lines []LyricLine, paragraphs [][2]uint64) bool {
+	relativeFits, absoluteFits, hasEnd := true, true, false
+	for i, line := range lines {
+		begin, end := paragraphs[i][0], paragraphs[i][1]
+		hasEnd = hasEnd || end != 0
+		for _, words := range [][]LyricWord{line.Words, line.Background} {
+			for _, word := range words {
+				if end != 0 && word.TimeUs+begin > end {
+					relativeFits = false
+				}
+				if word.TimeUs < begin || end != 0 && word.TimeUs > end {
+					absoluteFits = false
+				}
+			}
+		}
+	}
+	if !absoluteFits || !relativeFits {
+		return !absoluteFits
+	}
+	return hasEnd
+}
+
+// shiftSyllables Move the syllables by the begin of their paragraph.
+// 按所属段落的开始时间平移音节。
+func shiftSyllables(words []LyricWord, begin uint64) {
+	for i := range words {
+		words[i].TimeUs += begin
+		if words[i].EndUs != 0 {
+			words[i].EndUs += begin
+		}
+	}
+}
+
+// trimWords Remove empty syllables and the whitespace around the first and the last one.
+// 移除空音节以及第一个和最后一个音节两侧的空白。
+func trimWords(words []LyricWord) []LyricWord {
+	var trimmed []LyricWord
+	for _, word := range words {
+		if word.Text != "" {
+			trimmed = append(trimmed, word)
+		}
+	}
+	if len(trimmed) == 0 {
+		return nil
+	}
+	trimmed[0].Text = strings.TrimLeft(trimmed[0].Text, " ")
+	trimmed[len(trimmed)-1].Text = strings.TrimRight(trimmed[len(trimmed)-1].Text, " ")
+	return trimmed
+}
+
+// ttmlTime Read a time attribute of the element, reporting it if it is invalid.
+// 读取元素的时间属性，无效时报告该问题。
+func (p *lyricParser) ttmlTime(element xml.StartElement, name string, line, column int) (uint64, bool) {
+	value := ttmlAttr(element, name)
+	if value == "" {
+		return 0, false
+	}
+	us, err := parseTTMLTime(value)
+	if err != nil {
+		_ = p.report(line, column, SeverityWarning, "invalid %s time %q: %v", name, value, err)
+		return 0, false
+	}
+	return us, true
+}
+
+// ttmlAttr Get the value of an attribute by its local name, ignoring the namespace.
+// 按本地名称获取属性值，忽略命名空间。
+func ttmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// parseTTMLTime Parse a TTML time expression such as 01:02.500, 00:01:02.500, 62.5, 62.5s or 500ms into microseconds.
+// 将TTML时间表达式（例如01:02.500、00:01:02.500、62.5、62.5s或500ms）解析为微妙。
+func parseTTMLTime(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, ":") {
+		if strings.Count(value, ":") == 2 && !strings.Contains(value, ".") {
+			//hh:mm:ss, without the fraction it would be read as mm:ss:xx.
+			//hh:mm:ss，没有小数部分时会被当作mm:ss:xx。
+			value += ".0"
+		}
+		return parseTimestamp(value)
+	}
+	units := []struct {
+		suffix string
+		us     float64
+	}{{"ms", 1_000}, {"h", 3_600_000_000}, {"m", 60_000_000}, {"s", 1_000_000}}
+	scale := 1_000_000.0
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, scale = strings.TrimSuffix(value, unit.suffix), unit.us
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("unrecognized time expression")
+	}
+	return uint64(number*scale + 0.5), nil
+}
+
+// positionOf Convert a byte offset in the text into a line and a column starting from 1.
+// 将文本中的字节偏移量转换为从1开始的行号和列号。
+func positionOf(text string, offset int64) (int, int) {
+	before := text[:min(offset, int64(len(text)))]
+	lineStart := strings.LastIndex(before, "\n") + 1
+	return strings.Count(before, "\n") + 1, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+// normalizeSpace Replace every run of whitespace with a single space.
+// 将每一段连续的空白替换为单个空格。
+func normalizeSpace(text string) string {
+	var buf bytes.Buffer
+	space := false
+	for _, r := range text {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			if !space {
+				buf.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
+
+// formatTimestamp Format microseconds as mm:ss.xx for messages.
+// 将微妙格式化为mm:ss.xx，用于消息。
+func formatTimestamp(us uint64) string {
+	return fmt.Sprintf("%02d:%02d.%02d", us/60_000_000, us/1_000_000%60, us/10_000%100)
+}
@@ -0,0 +1,128 @@
+package lyrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTTMLSyllableTiming(t *testing.T) {
+	tests := []struct {
+		name  string
+		tt    string
+		body  string
+		lines [][]uint64 //The line time followed by its syllable times. 行时间及其音节时间。
+	}{
+		{
+			name:  "relative spans past the paragraph begin",
+			body:  `<p begin="00:05.000" end="00:15.000"><span begin="7s" end="8s">late</span></p>`,
+			lines: [][]uint64{{5_000_000, 12_000_000}},
+		},
+		{
+			name: "relative spans",
+			body: `<p begin="00:05.000" end="00:08.000"><span begin="0s" end="1s">a</span> <span begin="1s" end="2s">b</span></p>
+<p begin="00:10.000" end="00:12.000"><span begin="0.5s" end="1s">c</span></p>`,
+			lines: [][]uint64{{5_000_000, 5_000_000, 6_000_000}, {10_000_000, 10_500_000}},
+		},
+		{
+			name: "absolute spans that overflow as relative times",
+			body: `<p begin="00:05.000" end="00:08.000"><span begin="00:05.000" end="00:06.000">a</span> <span begin="00:07.000" end="00:08.000">b</span></p>
+<p begin="00:10.000" end="00:12.000"><span begin="00:10.500" end="00:11.000">c</span></p>`,
+			lines: [][]uint64{{5_000_000, 5_000_000, 7_000_000}, {10_000_000, 10_500_000}},
+		},
+		{
+			name:  "absolute spans without paragraph ends",
+			body:  `<p begin="00:05.000"><span begin="00:05.000">a</span> <span begin="00:06.000">b</span></p>`,
+			lines: [][]uint64{{5_000_000, 5_000_000, 6_000_000}},
+		},
+		{
+			name:  "relative spans without paragraph ends",
+			body:  `<p begin="00:05.000"><span begin="0s">a</span> <span begin="1s">b</span></p>`,
+			lines: [][]uint64{{5_000_000, 5_000_000, 6_000_000}},
+		},
+		{
+			name:  "iTunes timing",
+			tt:    ` xmlns:itunes="http://music.apple.com/lyric-ttml-internal"`,
+			body:  `<p begin="00:05.000" end="00:15.000"><span begin="00:07.000" end="00:08.000">late</span></p>`,
+			lines: [][]uint64{{5_000_000, 7_000_000}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := `<tt xmlns="http://www.w3.org/ns/ttml"` + tt.tt + `><body><div>` + tt.body + `</div></body></tt>`
+			lyric, _, err := ParseLyric(strings.NewReader(content), "test.ttml", 0, ParseOptions{Format: ".ttml"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lyric.Lines) != len(tt.lines) {
+				t.Fatalf("got %d lines, want %d", len(lyric.Lines), len(tt.lines))
+			}
+			for i, line := range lyric.Lines {
+				got := []uint64{line.TimeUs}
+				for _, word := range line.Words {
+					got = append(got, word.TimeUs)
+				}
+				if !equalTimes(got, tt.lines[i]) {
+					t.Errorf("line %d times = %v, want %v", i, got, tt.lines[i])
+				}
+			}
+		})
+	}
+}
+
+func equalTimes(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseTTMLDocument(t *testing.T) {
+	const document = `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" xmlns:itunes="http://music.apple.com/lyric-ttml-internal">
+<head>
+  <metadata>
+    <ttm:title>Song</ttm:title>
+    <ttm:agent type="person" xml:id="v1"><ttm:name type="full">Singer</ttm:name></ttm:agent>
+    <iTunesMetadata xmlns="http://music.apple.com/lyric-ttml-internal">
+      <translations><translation lang="zh"><text for="L1">你好</text></translation></translations>
+      <transliterations><transliteration lang="ja-Latn"><text for="L1">ha ro</text></transliteration></transliterations>
+    </iTunesMetadata>
+  </metadata>
+</head>
+<body>
+  <div>
+    <p begin="00:01.000" end="00:03.000" ttm:agent="v1" itunes:key="L1"><span begin="00:01.000" end="00:02.000">Hel</span><span begin="00:02.000" end="00:03.000">lo</span></p>
+    <p begin="00:04.000" end="00:06.000" ttm:agent="v2" itunes:key="L2"><span begin="00:04.000" end="00:05.000">Again</span><span ttm:role="x-bg"><span begin="00:05.000" end="00:06.000">(ooh)</span></span></p>
+    <p begin="00:07.000" end="00:08.000">World<span ttm:role="x-translation" xml:lang="zh">世界</span></p>
+  </div>
+</body>
+</tt>`
+	lyric, _, err := ParseLyric(strings.NewReader(document), "test.ttml", 0, ParseOptions{Format: ".ttml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lyric.Metadata.Title != "Song" {
+		t.Errorf("title = %q, want %q", lyric.Metadata.Title, "Song")
+	}
+	if len(lyric.Lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lyric.Lines))
+	}
+	first, second, third := lyric.Lines[0], lyric.Lines[1], lyric.Lines[2]
+	//The agent is named in the head, the translation and transliteration are found by the iTunes key.
+	//歌手名在头部给出，翻译和音译通过iTunes key查找。
+	if first.Text != "Hello" || first.Agent != "Singer" || first.Translation != "你好" || first.Romanization != "ha ro" {
+		t.Errorf("first line = %+v", first)
+	}
+	//An agent without a name keeps its id.
+	//没有名字的歌手保留其id。
+	if second.Text != "Again" || second.Agent != "v2" || joinWords(second.Background) != "(ooh)" || second.Background[0].TimeUs != 5_000_000 {
+		t.Errorf("second line = %+v", second)
+	}
+	if third.Text != "World" || third.Translation != "世界" || len(third.Words) != 0 {
+		t.Errorf("third line = %+v", third)
+	}
+}
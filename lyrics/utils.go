@@ -6,6 +6,7 @@ package lyrics
 */
 import "C"
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"unsafe"
@@ -23,6 +24,36 @@ func isAudioFile(path string) bool {
 	}
 }
 
+// lyricPathFor Get the path of the lyric file with the extension next to the audio file, for example a.flac matches a.lrc.
+// 获取与音频文件同级且具有指定扩展名的歌词文件路径，例如a.flac对应a.lrc。
+func lyricPathFor(audioPath, ext string) string {
+	return strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) + ext
+}
+
+// writeFileAtomic Write the file through a temporary file in the same directory, so that readers never see it half
+// written.
+// 通过同一目录中的临时文件写入文件，使读取者不会看到写了一半的文件。
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 // WriteCString 将 Go 字符串 s 写入 ptr 指向的共享内存（带 '\0' 结尾）
 // Write the Go string 's' to the shared memory pointed to by ptr (ending with '\0')
 func WriteCString(ptr unsafe.Pointer, s string, maxLen int) {